@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/axard/things/pkg/hookgen"
+	"github.com/axard/things/pkg/resource"
+)
+
+// TConfig lists hooks generated by one run of hookgen like:
+//
+//	{
+//	    "hooks": [
+//	        {"src": "./events.Listener", "dst": "./events.Hook", "file": "hook.go", "safe": true},
+//	        {"src": "./events.Closer", "dst": "./events.CloseHook", "file": "close.go", "dispatch": "parallel"}
+//	    ]
+//	}
+//
+// Relative paths are relative to the directory of the config file, import
+// paths are allowed too. Options which aren't set have the same defaults
+// as flags.
+type TConfig struct {
+	Hooks []THookConfig `json:"hooks"`
+}
+
+type THookConfig struct {
+	Src  string `json:"src"`
+	Dst  string `json:"dst"`
+	File string `json:"file"`
+
+	Safe bool   `json:"safe"`
+	Sync string `json:"sync"`
+
+	Formatter string `json:"fmt"`
+	Results   string `json:"results"`
+
+	Dispatch    string `json:"dispatch"`
+	Concurrency int    `json:"concurrency"`
+	QueueSize   int    `json:"queue"`
+	Overflow    string `json:"overflow"`
+
+	Order   string `json:"order"`
+	Once    bool   `json:"once"`
+	Recover bool   `json:"recover"`
+	Funcs   bool   `json:"funcs"`
+
+	Recorder bool `json:"recorder"`
+	Tests    bool `json:"tests"`
+
+	// Template is the path to the template used instead of the built-in one
+	Template string `json:"template"`
+}
+
+func readConfig(filename string) (*TConfig, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &TConfig{}
+	if err := json.Unmarshal(b, config); err != nil {
+		return nil, fmt.Errorf("config '%s': %s", filename, err)
+	}
+
+	dir := filepath.Dir(filename)
+
+	for i := range config.Hooks {
+		hook := &config.Hooks[i]
+
+		if hook.Src == "" || resource.Object(hook.Src) == "" {
+			return nil, fmt.Errorf("config '%s': hook #%d: invalid 'src': '%s'", filename, i, hook.Src)
+		}
+
+		if hook.Dst == "" {
+			return nil, fmt.Errorf("config '%s': hook #%d: 'dst' can't be empty", filename, i)
+		}
+
+		if hook.File == "" {
+			hook.File = "generated.go"
+		}
+
+		hook.Src = relativeTo(dir, hook.Src)
+		hook.Dst = relativeTo(dir, hook.Dst)
+
+		if hook.Template != "" && !filepath.IsAbs(hook.Template) {
+			hook.Template = filepath.Join(dir, hook.Template)
+		}
+	}
+
+	return config, nil
+}
+
+func relativeTo(dir, path string) string {
+	if !resource.IsLocal(path) || filepath.IsAbs(path) {
+		return path
+	}
+
+	path = filepath.Join(dir, path)
+	if resource.IsLocal(path) {
+		return path
+	}
+
+	return "." + string(filepath.Separator) + path
+}
+
+func (this *THookConfig) Job() Job {
+	return Job{
+		Hookgen: hookgen.Hookgen{
+			Src:         this.Src,
+			Dst:         this.Dst,
+			Safe:        this.Safe,
+			Sync:        this.Sync,
+			Results:     this.Results,
+			Dispatch:    this.Dispatch,
+			Concurrency: this.Concurrency,
+			QueueSize:   this.QueueSize,
+			Overflow:    this.Overflow,
+			Order:       this.Order,
+			Once:        this.Once,
+			Recover:     this.Recover,
+			Funcs:       this.Funcs,
+			Recorder:    this.Recorder,
+			Formatter:   this.Formatter,
+		},
+		File:  this.File,
+		Tests: this.Tests,
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_relativeTo(t *testing.T) {
+	type args struct {
+		dir  string
+		path string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "relative path is joined with relative dir",
+			args: args{dir: "configs", path: "./events.Listener"},
+			want: "./configs/events.Listener",
+		},
+		{
+			name: "parent path is joined with relative dir",
+			args: args{dir: "configs/hooks", path: "../events.Listener"},
+			want: "./configs/events.Listener",
+		},
+		{
+			name: "relative path is joined with absolute dir",
+			args: args{dir: "/project/configs", path: "../events.Listener"},
+			want: "/project/events.Listener",
+		},
+		{
+			name: "path leaving relative dir stays relative",
+			args: args{dir: ".", path: "../events.Listener"},
+			want: "../events.Listener",
+		},
+		{
+			name: "absolute path isn't changed",
+			args: args{dir: "configs", path: "/project/events.Listener"},
+			want: "/project/events.Listener",
+		},
+		{
+			name: "import path isn't changed",
+			args: args{dir: "configs", path: "github.com/user/events.Listener"},
+			want: "github.com/user/events.Listener",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := relativeTo(tt.args.dir, tt.args.path); got != tt.want {
+				t.Errorf("relativeTo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_readConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		config  string
+		want    []THookConfig
+		wantErr bool
+	}{
+		{
+			name: "readConfig() resolves paths relative to the config",
+			config: `{"hooks": [
+				{"src": "./events.Listener", "dst": "./events.Hook", "file": "hook.go", "safe": true, "template": "hook.tmpl"},
+				{"src": "github.com/user/events.Closer", "dst": "../out.CloseHook", "dispatch": "parallel", "recorder": true}
+			]}`,
+			want: []THookConfig{
+				{
+					Src:      filepath.Join(dir, "events.Listener"),
+					Dst:      filepath.Join(dir, "events.Hook"),
+					File:     "hook.go",
+					Safe:     true,
+					Template: filepath.Join(dir, "hook.tmpl"),
+				},
+				{
+					Src:      "github.com/user/events.Closer",
+					Dst:      filepath.Join(filepath.Dir(dir), "out.CloseHook"),
+					File:     "generated.go",
+					Dispatch: "parallel",
+					Recorder: true,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "readConfig() fails on source without interface",
+			config:  `{"hooks": [{"src": "./events", "dst": "./events.Hook"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "readConfig() fails on empty destination",
+			config:  `{"hooks": [{"src": "./events.Listener"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "readConfig() fails on invalid JSON",
+			config:  `{"hooks": [`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(dir, "hooks.json")
+			if err := ioutil.WriteFile(filename, []byte(tt.config), FilePermission); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := readConfig(filename)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(got.Hooks, tt.want) {
+				t.Errorf("readConfig() = %+v, want %+v", got.Hooks, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/axard/things/pkg/hookgen"
+	"github.com/axard/things/pkg/resource"
+)
+
+// discover finds interfaces marked with hookgen.Directive in packages of
+// Patterns and returns jobs generating their hooks
+func (this *TFlags) discover() ([]Job, []*hookgen.Interface, error) {
+	annotations, err := hookgen.Discover(this.Patterns...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(annotations) == 0 {
+		return nil, nil, fmt.Errorf("there are no interfaces marked with '%s' in '%s'", hookgen.Directive, strings.Join(this.Patterns, " "))
+	}
+
+	jobs := make([]Job, 0, len(annotations))
+	ifaces := make([]*hookgen.Interface, 0, len(annotations))
+
+	for _, a := range annotations {
+		job, err := this.directiveJob(a)
+		if err != nil {
+			return nil, nil, &hookgen.PackageLoadError{
+				Pkg:    a.Interface.PkgPath,
+				Errors: []hookgen.PositionError{{Pos: a.Pos, Msg: fmt.Sprintf("invalid directive: %s", err)}},
+			}
+		}
+
+		if err := job.resolveDst(a.Interface.Package); err != nil {
+			return nil, nil, err
+		}
+
+		job.Hookgen.Docs = a.Interface.Docs
+		ifaces = append(ifaces, a.Interface)
+		jobs = append(jobs, job)
+	}
+
+	return jobs, ifaces, nil
+}
+
+// directiveJob returns the job of the directive, its words are flags of
+// options without "-" which override flags of the command line. Besides
+// them there are name=HookName and dst=path/to/package[.HookName], relative
+// paths are relative to the directory of the directive. The hook is named
+// <Interface>Hook and it's written into <hook>_generated.go next to the
+// interface if they aren't set.
+func (this *TFlags) directiveJob(a hookgen.Annotation) (Job, error) {
+	flags := *this
+	flags.File = ""
+
+	name, dst := "", ""
+
+	fs := flag.NewFlagSet(hookgen.Directive, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+
+	flags.optionFlags(fs)
+	fs.StringVar(&flags.File, "file", "", "")
+	fs.StringVar(&name, "name", "", "")
+	fs.StringVar(&dst, "dst", "", "")
+
+	args := make([]string, 0, len(a.Args))
+	for _, arg := range a.Args {
+		args = append(args, "-"+arg)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return Job{}, err
+	}
+
+	dir := filepath.Dir(a.Pos.Filename)
+
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "template" && !filepath.IsAbs(flags.Template) {
+			flags.Template = filepath.Join(dir, flags.Template)
+		}
+	})
+
+	if name == "" {
+		name = resource.Object(dst)
+	}
+
+	if name == "" {
+		name = a.Interface.Name + "Hook"
+	}
+
+	pkg := a.Interface.PkgPath
+	if dst != "" {
+		pkg = relativeTo(dir, resource.Package(dst))
+	}
+
+	if flags.File == "" {
+		flags.File = strings.ToLower(name) + "_generated.go"
+	}
+
+	flags.PathToSrc = a.Interface.Src()
+	flags.PathToDst = pkg + "." + name
+
+	return flags.Job()
+}
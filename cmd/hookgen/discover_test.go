@@ -0,0 +1,172 @@
+package main
+
+import (
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/axard/things/pkg/hookgen"
+)
+
+func TestTFlags_directiveJob(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "hook.tmpl"), []byte("package {{.PackageName}}"), FilePermission); err != nil {
+		t.Fatal(err)
+	}
+
+	// job is the part of Job set by directives
+	type job struct {
+		Src       string
+		Dst       string
+		File      string
+		Safe      bool
+		Dispatch  string
+		Formatter string
+		Template  string
+	}
+
+	withFlags := func(set func(flags *TFlags)) TFlags {
+		flags := Flags
+		set(&flags)
+
+		return flags
+	}
+
+	tests := []struct {
+		name    string
+		flags   TFlags
+		args    []string
+		want    job
+		wantErr bool
+	}{
+		{
+			name:  "directiveJob() names hook and file after the interface",
+			flags: Flags,
+			args:  []string{},
+			want: job{
+				Src:       "example.com/events.Listener",
+				Dst:       "example.com/events.ListenerHook",
+				File:      "listenerhook_generated.go",
+				Dispatch:  hookgen.DispatchSequential,
+				Formatter: "gofmt",
+			},
+			wantErr: false,
+		},
+		{
+			name:  "directiveJob() sets name and options of hook",
+			flags: Flags,
+			args:  []string{"name=ShutdownHook", "safe", "dispatch=parallel"},
+			want: job{
+				Src:       "example.com/events.Listener",
+				Dst:       "example.com/events.ShutdownHook",
+				File:      "shutdownhook_generated.go",
+				Safe:      true,
+				Dispatch:  hookgen.DispatchParallel,
+				Formatter: "gofmt",
+			},
+			wantErr: false,
+		},
+		{
+			name:  "directiveJob() resolves dst relative to the directive",
+			flags: Flags,
+			args:  []string{"dst=../out", "file=hook.go"},
+			want: job{
+				Src:       "example.com/events.Listener",
+				Dst:       filepath.Join(filepath.Dir(dir), "out.ListenerHook"),
+				File:      "hook.go",
+				Dispatch:  hookgen.DispatchSequential,
+				Formatter: "gofmt",
+			},
+			wantErr: false,
+		},
+		{
+			name:  "directiveJob() takes name of hook from dst",
+			flags: Flags,
+			args:  []string{"dst=./out.Other"},
+			want: job{
+				Src:       "example.com/events.Listener",
+				Dst:       filepath.Join(dir, "out.Other"),
+				File:      "other_generated.go",
+				Dispatch:  hookgen.DispatchSequential,
+				Formatter: "gofmt",
+			},
+			wantErr: false,
+		},
+		{
+			name: "directiveJob() overrides flags of the command line",
+			flags: withFlags(func(flags *TFlags) {
+				flags.Safe = true
+				flags.Formatter = "goimports"
+				flags.File = "hooks.go"
+			}),
+			args: []string{"safe=false"},
+			want: job{
+				Src:       "example.com/events.Listener",
+				Dst:       "example.com/events.ListenerHook",
+				File:      "listenerhook_generated.go",
+				Safe:      false,
+				Dispatch:  hookgen.DispatchSequential,
+				Formatter: "goimports",
+			},
+			wantErr: false,
+		},
+		{
+			name:  "directiveJob() reads template relative to the directive",
+			flags: Flags,
+			args:  []string{"template=hook.tmpl"},
+			want: job{
+				Src:       "example.com/events.Listener",
+				Dst:       "example.com/events.ListenerHook",
+				File:      "listenerhook_generated.go",
+				Dispatch:  hookgen.DispatchSequential,
+				Formatter: "gofmt",
+				Template:  "package {{.PackageName}}",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "directiveJob() fails on unknown option",
+			flags:   Flags,
+			args:    []string{"bogus"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := hookgen.Annotation{
+				Interface: &hookgen.Interface{
+					PkgPath: "example.com/events",
+					PkgName: "events",
+					Name:    "Listener",
+				},
+				Args: tt.args,
+				Pos:  token.Position{Filename: filepath.Join(dir, "events.go"), Line: 3, Column: 1},
+			}
+
+			got, err := tt.flags.directiveJob(a)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TFlags.directiveJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			gotJob := job{
+				Src:       got.Hookgen.Src,
+				Dst:       got.Hookgen.Dst,
+				File:      got.File,
+				Safe:      got.Hookgen.Safe,
+				Dispatch:  got.Hookgen.Dispatch,
+				Formatter: got.Hookgen.Formatter,
+				Template:  got.Hookgen.Template,
+			}
+
+			if !reflect.DeepEqual(gotJob, tt.want) {
+				t.Errorf("TFlags.directiveJob() = %+v, want %+v", gotJob, tt.want)
+			}
+		})
+	}
+}
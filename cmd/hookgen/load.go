@@ -0,0 +1,392 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/axard/things/pkg/hookgen"
+	"github.com/axard/things/pkg/resource"
+	"golang.org/x/tools/go/packages"
+)
+
+// Stdin is the package of Src which means the source read from stdin
+const Stdin = "-"
+
+// load loads source interfaces of jobs, every package is loaded once by one
+// call of packages.Load. Generic interfaces are instantiated if Src has type
+// arguments. Src and Dst of jobs are replaced with import paths where it's
+// possible, so the generator can compare them, and Dir is set.
+func load(jobs []Job) ([]*hookgen.Interface, error) {
+	srcs := []string{}
+	for i := range jobs {
+		if resource.Package(jobs[i].Hookgen.Src) != Stdin {
+			srcs = append(srcs, jobs[i].Hookgen.Src)
+		}
+	}
+
+	loaded, err := hookgen.LoadAll(srcs)
+	if err != nil {
+		return nil, err
+	}
+
+	ifaces := make([]*hookgen.Interface, 0, len(jobs))
+	for i := range jobs {
+		job := &jobs[i]
+
+		var iface *hookgen.Interface
+
+		if resource.Package(job.Hookgen.Src) == Stdin {
+			p, err := job.loadStdin()
+			if err != nil {
+				return nil, err
+			}
+
+			if iface, err = hookgen.LookupInterface(p, strings.TrimPrefix(job.Hookgen.Src, Stdin+".")); err != nil {
+				return nil, err
+			}
+		} else {
+			iface, loaded = loaded[0], loaded[1:]
+		}
+
+		if err := job.resolveDst(iface.Package); err != nil {
+			return nil, err
+		}
+
+		job.Hookgen.Docs = iface.Docs
+		job.Hookgen.Src = iface.Src()
+		ifaces = append(ifaces, iface)
+	}
+
+	return ifaces, nil
+}
+
+// resolveDst sets Dir and the name of the package of the job and replaces
+// local Dst with import path if it's inside the module of the source package
+// or the main module
+func (this *Job) resolveDst(src *packages.Package) error {
+	if err := this.resolveDstPath(src); err != nil {
+		return err
+	}
+
+	if src.PkgPath != "" && src.PkgPath == resource.Package(this.Hookgen.Dst) {
+		this.Hookgen.Package = src.Name
+	} else {
+		this.Hookgen.Package = this.dstPackageName()
+	}
+
+	return nil
+}
+
+func (this *Job) resolveDstPath(src *packages.Package) error {
+	pkg, name := resource.Package(this.Hookgen.Dst), resource.Object(this.Hookgen.Dst)
+
+	withName := func(pkg string) string {
+		if name == "" {
+			return pkg
+		}
+
+		return pkg + "." + name
+	}
+
+	mods, err := modules(src)
+	if err != nil {
+		return err
+	}
+
+	if resource.IsLocal(pkg) {
+		this.Dir = pkg
+
+		abs, err := filepath.Abs(pkg)
+		if err != nil {
+			return err
+		}
+
+		for _, mod := range mods {
+			rel, err := filepath.Rel(mod.Dir, abs)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue
+			}
+
+			this.Hookgen.Dst = withName(path.Join(mod.Path, filepath.ToSlash(rel)))
+
+			return nil
+		}
+
+		return nil
+	}
+
+	for _, mod := range mods {
+		if pkg == mod.Path || strings.HasPrefix(pkg, mod.Path+"/") {
+			this.Dir = filepath.Join(mod.Dir, filepath.FromSlash(strings.TrimPrefix(pkg, mod.Path)))
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("can't write hook into package '%s' outside of the main module", pkg)
+}
+
+// dstPackageName returns the name of the package in Dir declared by its
+// files except generated ones, it's empty if the package doesn't exist yet
+func (this *Job) dstPackageName() string {
+	if this.Dir == "" {
+		return ""
+	}
+
+	entries, err := ioutil.ReadDir(this.Dir)
+	if err != nil {
+		return ""
+	}
+
+	generated := map[string]bool{
+		filepath.Base(this.Filename()):     true,
+		filepath.Base(this.TestFilename()): true,
+	}
+
+	fset := token.NewFileSet()
+
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || generated[name] || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		if ok, err := build.Default.MatchFile(this.Dir, name); err != nil || !ok {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, filepath.Join(this.Dir, name), nil, parser.PackageClauseOnly)
+		if err != nil {
+			continue
+		}
+
+		return f.Name.Name
+	}
+
+	return ""
+}
+
+var mainModules []*packages.Module
+
+// modules returns the module of the source package and the main modules
+func modules(src *packages.Package) ([]*packages.Module, error) {
+	if mainModules == nil {
+		out, err := exec.Command("go", "list", "-m", "-f", "{{.Path}}\t{{.Dir}}").Output()
+		if err != nil {
+			return nil, fmt.Errorf("go list -m: %s", err)
+		}
+
+		mainModules = []*packages.Module{}
+
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			fields := strings.SplitN(line, "\t", 2)
+			if len(fields) != 2 || fields[1] == "" {
+				continue
+			}
+
+			mainModules = append(mainModules, &packages.Module{Path: fields[0], Dir: fields[1]})
+		}
+	}
+
+	if src.Module == nil {
+		return mainModules, nil
+	}
+
+	return append([]*packages.Module{src.Module}, mainModules...), nil
+}
+
+var stdin []byte
+
+// loadStdin type checks the source read from stdin as a file of the package
+// of Dst, it replaces files of the package declaring the same names, so
+// the source may be the unsaved version of one of them. The declaration
+// without package clause is allowed too. Errors outside of the source are
+// ignored, the package may use the hook which isn't generated yet.
+func (this *Job) loadStdin() (*packages.Package, error) {
+	if err := this.resolveDst(&packages.Package{}); err != nil {
+		return nil, err
+	}
+
+	pkgPath := resource.Package(this.Hookgen.Dst)
+
+	if stdin == nil {
+		b, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("stdin: %s", err)
+		}
+
+		stdin = b
+	}
+
+	fset := token.NewFileSet()
+	name := path.Base(pkgPath)
+	files := []*ast.File{}
+	imported := map[string]*types.Package{}
+
+	ps, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes,
+		Fset: fset,
+	}, pkgPath)
+	if err == nil && len(ps) == 1 && len(ps[0].Syntax) != 0 {
+		name = ps[0].Name
+		files = ps[0].Syntax
+
+		for _, p := range ps[0].Types.Imports() {
+			imported[p.Path()] = p
+		}
+	}
+
+	src := stdin
+	if _, err := parser.ParseFile(fset, "stdin", src, parser.PackageClauseOnly); err != nil {
+		src = append([]byte("package "+name+"\n//line stdin:1:1\n"), src...)
+	}
+
+	f, err := parser.ParseFile(fset, "stdin", src, parser.ParseComments)
+	if list, ok := err.(scanner.ErrorList); ok {
+		lerr := &hookgen.PackageLoadError{Pkg: pkgPath}
+		for _, e := range list {
+			lerr.Errors = append(lerr.Errors, hookgen.PositionError{Pos: e.Pos, Msg: e.Msg})
+		}
+
+		return nil, lerr
+	} else if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for _, spec := range f.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		if imported[p] == nil {
+			paths = append(paths, p)
+		}
+	}
+
+	if len(paths) != 0 {
+		deps, err := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedTypes}, paths...)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dep := range deps {
+			imported[dep.PkgPath] = dep.Types
+		}
+	}
+
+	files = append(withoutDecls(files, f), f)
+
+	lerr := &hookgen.PackageLoadError{Pkg: pkgPath}
+	config := types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if p := imported[path]; p != nil {
+				return p, nil
+			}
+
+			return nil, fmt.Errorf("can't import package '%s'", path)
+		}),
+		Error: func(err error) {
+			terr, ok := err.(types.Error)
+			if !ok {
+				lerr.Errors = append(lerr.Errors, hookgen.PositionError{Msg: err.Error()})
+				return
+			}
+
+			if terr.Fset.File(terr.Pos) != fset.File(f.Pos()) {
+				return
+			}
+
+			lerr.Errors = append(lerr.Errors, hookgen.PositionError{
+				Pos: terr.Fset.Position(terr.Pos),
+				Msg: terr.Msg,
+			})
+		},
+	}
+
+	p, _ := config.Check(pkgPath, fset, files, nil)
+	if len(lerr.Errors) != 0 {
+		return nil, lerr
+	}
+
+	return &packages.Package{
+		ID:      Stdin,
+		Name:    p.Name(),
+		PkgPath: pkgPath,
+		Fset:    fset,
+		Syntax:  files,
+		Types:   p,
+	}, nil
+}
+
+// withoutDecls returns files which don't declare names declared by f
+func withoutDecls(files []*ast.File, f *ast.File) []*ast.File {
+	names := declNames(f)
+	rest := []*ast.File{}
+
+	for _, file := range files {
+		clash := false
+		for name := range declNames(file) {
+			if names[name] {
+				clash = true
+				break
+			}
+		}
+
+		if !clash {
+			rest = append(rest, file)
+		}
+	}
+
+	return rest
+}
+
+// declNames returns names declared at the package level of the file,
+// methods are named like "Type.Method"
+func declNames(f *ast.File) map[string]bool {
+	names := map[string]bool{}
+
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			name := decl.Name.Name
+			if decl.Recv != nil && len(decl.Recv.List) != 0 {
+				name = types.ExprString(decl.Recv.List[0].Type) + "." + name
+			}
+
+			names[name] = true
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names[spec.Name.Name] = true
+				case *ast.ValueSpec:
+					for _, n := range spec.Names {
+						names[n.Name] = true
+					}
+				}
+			}
+		}
+	}
+
+	return names
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (this importerFunc) Import(path string) (*types.Package, error) {
+	return this(path)
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/axard/things/pkg/hookgen"
+)
+
+func TestJob_loadStdin(t *testing.T) {
+	tests := []struct {
+		name        string
+		stdin       string
+		iface       string
+		wantMethods []string
+		wantErrPos  string
+		wantErr     bool
+	}{
+		{
+			name:        "loadStdin() loads source with package clause",
+			stdin:       "package events\n\ntype Closer interface {\n\tClose() error\n}\n",
+			iface:       "Closer",
+			wantMethods: []string{"Close"},
+			wantErr:     false,
+		},
+		{
+			name:        "loadStdin() loads declaration without package clause",
+			stdin:       "type Closer interface { Close() error }",
+			iface:       "Closer",
+			wantMethods: []string{"Close"},
+			wantErr:     false,
+		},
+		{
+			name:        "loadStdin() replaces file declaring the same type",
+			stdin:       "type Listener interface {\n\tNotify(e string)\n\tStop()\n}\n",
+			iface:       "Listener",
+			wantMethods: []string{"Notify", "Stop"},
+			wantErr:     false,
+		},
+		{
+			name:        "loadStdin() uses imports of the package",
+			stdin:       "import \"fmt\"\n\ntype Printer interface { Print(s fmt.Stringer) }",
+			iface:       "Printer",
+			wantMethods: []string{"Print"},
+			wantErr:     false,
+		},
+		{
+			name:       "loadStdin() reports syntax error at its position in stdin",
+			stdin:      "type Closer interface {\n\tClose(\n}\n",
+			wantErrPos: "stdin:3:1",
+			wantErr:    true,
+		},
+		{
+			name:       "loadStdin() reports type error at its position in stdin",
+			stdin:      "type Closer interface {\n\tClose(u Undefined)\n}\n",
+			wantErrPos: "stdin:2:10",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdin = []byte(tt.stdin)
+			defer func() { stdin = nil }()
+
+			this := &Job{Hookgen: hookgen.Hookgen{Dst: "./testdata/events.Hook"}}
+
+			p, err := this.loadStdin()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Job.loadStdin() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				var lerr *hookgen.PackageLoadError
+				if !errors.As(err, &lerr) || len(lerr.Errors) == 0 {
+					t.Fatalf("Job.loadStdin() error = %v, want PackageLoadError", err)
+				}
+
+				if got := lerr.Errors[0].Pos.String(); got != tt.wantErrPos {
+					t.Errorf("Job.loadStdin() error at %v, want %v", got, tt.wantErrPos)
+				}
+
+				return
+			}
+
+			iface, err := hookgen.LookupInterface(p, tt.iface)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			methods := []string{}
+			for _, m := range iface.Methods {
+				methods = append(methods, m.Name)
+			}
+
+			if !reflect.DeepEqual(methods, tt.wantMethods) {
+				t.Errorf("Job.loadStdin() methods of %s = %v, want %v", tt.iface, methods, tt.wantMethods)
+			}
+		})
+	}
+}
+
+func Test_withoutDecls(t *testing.T) {
+	mustParse := func(src string) *ast.File {
+		f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return f
+	}
+
+	files := []*ast.File{
+		mustParse("package p\n\ntype A interface{}"),
+		mustParse("package p\n\nvar b, c int"),
+		mustParse("package p\n\ntype T struct{}\n\nfunc (t *T) M() {}"),
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		want []int
+	}{
+		{
+			name: "withoutDecls() drops file declaring the same type",
+			src:  "package p\n\ntype A interface{ M() }",
+			want: []int{1, 2},
+		},
+		{
+			name: "withoutDecls() drops file declaring the same variable",
+			src:  "package p\n\nvar c string",
+			want: []int{0, 2},
+		},
+		{
+			name: "withoutDecls() drops file declaring the same method",
+			src:  "package p\n\nfunc (t *T) M() {}",
+			want: []int{0, 1},
+		},
+		{
+			name: "withoutDecls() keeps files without clashes",
+			src:  "package p\n\ntype B interface{}\n\nfunc M() {}",
+			want: []int{0, 1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []int{}
+			for _, f := range withoutDecls(files, mustParse(tt.src)) {
+				for i := range files {
+					if files[i] == f {
+						got = append(got, i)
+					}
+				}
+			}
+
+			sort.Ints(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("withoutDecls() = files %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJob_dstPackageName(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "dstPackageName() returns name declared by files of the package",
+			files: map[string]string{
+				"ev.go":        "package ev\n",
+				"generated.go": "package goev\n",
+				"ev_test.go":   "package ev_test\n",
+				"tools.go":     "//go:build ignore\n\npackage main\n",
+			},
+			want: "ev",
+		},
+		{
+			name: "dstPackageName() returns nothing for new package",
+			files: map[string]string{
+				"generated.go": "package goev\n",
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "go-ev")
+			if err := os.Mkdir(dir, DirPermission); err != nil {
+				t.Fatal(err)
+			}
+
+			for name, src := range tt.files {
+				if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), FilePermission); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			this := &Job{Dir: dir, File: "generated.go"}
+			if got := this.dstPackageName(); got != tt.want {
+				t.Errorf("Job.dstPackageName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,27 +5,53 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"go/types"
+	"go/token"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/axard/things/pkg/diff"
 	"github.com/axard/things/pkg/hookgen"
 	"github.com/axard/things/pkg/resource"
-	"golang.org/x/tools/go/packages"
 )
 
 type TFlags struct {
 	Safe        bool
+	Sync        string
 	ShowVersion bool
 
 	Formatter string
+	Results   string
+
+	Dispatch    string
+	Concurrency int
+	QueueSize   int
+	Overflow    string
+
+	Order    string
+	Once     bool
+	Recover  bool
+	Funcs    bool
+	Recorder bool
 
 	PathToSrc string
 	PathToDst string
 
-	File string
+	File   string
+	Output string
+	Config string
+
+	Tests    bool
+	Template string
+
+	Check bool
+	Diff  bool
+
+	// Patterns are packages like "./..." scanned for interfaces marked with
+	// hookgen.Directive instead of -src and -dst
+	Patterns []string
 }
 
 const (
@@ -36,10 +62,38 @@ const (
 var (
 	ErrEmptyPathToSrc   = errors.New("flag '-src' can't be empty")
 	ErrEmptyPathToDst   = errors.New("flag '-dst' can't be empty")
-	ErrInvalidPathToSrc = errors.New("flag '-src' can't be empty")
+	ErrInvalidPathToSrc = errors.New("flag '-src' must be like path/to/package.InterfaceName")
+	ErrOutputWithConfig = errors.New("flag '-o' can't be used with '-config'")
+	ErrStdoutWithCheck  = errors.New("flag '-o -' can't be used with '-check' or '-diff'")
+	ErrStdoutWithTests  = errors.New("flag '-o -' can't be used with '-tests'")
+	ErrSrcWithPatterns  = errors.New("flags '-src', '-dst', '-o' and '-config' can't be used with packages")
 )
 
 func (this *TFlags) Validate() error {
+	if this.Output == Stdout && (this.Check || this.Diff) {
+		return ErrStdoutWithCheck
+	}
+
+	if this.Output == Stdout && this.Tests {
+		return ErrStdoutWithTests
+	}
+
+	if len(this.Patterns) != 0 {
+		if this.PathToSrc != "" || this.PathToDst != "" || this.Output != "" || this.Config != "" {
+			return ErrSrcWithPatterns
+		}
+
+		return nil
+	}
+
+	if this.Config != "" {
+		if this.Output != "" {
+			return ErrOutputWithConfig
+		}
+
+		return nil
+	}
+
 	if this.PathToSrc == "" {
 		return ErrEmptyPathToSrc
 	}
@@ -58,99 +112,373 @@ func (this *TFlags) Validate() error {
 var (
 	Version string = "unset"
 
-	Flags TFlags
+	// Flags are set to defaults of options, flags of the command line
+	// override them
+	Flags = TFlags{
+		Sync:      hookgen.SyncMutex,
+		Formatter: "gofmt",
+		Dispatch:  hookgen.DispatchSequential,
+		QueueSize: hookgen.DefaultQueueSize,
+		Overflow:  hookgen.OverflowBlock,
+		Order:     hookgen.OrderFIFO,
+		File:      "generated.go",
+	}
 )
 
 func init() {
-	flag.BoolVar(&Flags.Safe, "safe", false, "use mutex to protect hook methods")
 	flag.BoolVar(&Flags.ShowVersion, "version", false, "show the version for hoog")
-	flag.StringVar(&Flags.Formatter, "fmt", "gofmt", "go pretty-printer: gofmt, goimports or noop (default gofmt)")
-	flag.StringVar(&Flags.PathToSrc, "src", "", "path to interface like: /path/to/package.InterfaceName")
-	flag.StringVar(&Flags.PathToDst, "dst", "", "path to hook like: /path/to/package[.HookName]")
-	flag.StringVar(&Flags.File, "file", "generated.go", "name of generated file")
 
-	flag.Parse()
+	Flags.optionFlags(flag.CommandLine)
+
+	flag.StringVar(&Flags.PathToSrc, "src", "", "path to interface like: /path/to/package.InterfaceName or import/path.InterfaceName, generic one may be instantiated like: pkg.Listener[pkg.Event]; -.InterfaceName reads the source declaring it from stdin as a file of the package of -dst")
+	flag.StringVar(&Flags.PathToDst, "dst", "", "path to hook like: /path/to/package[.HookName] or import/path[.HookName]")
+	flag.StringVar(&Flags.File, "file", Flags.File, "name of generated file")
+	flag.StringVar(&Flags.Output, "o", "", "path to generated file instead of -file in the package of -dst, '-' writes it to stdout")
+	flag.StringVar(&Flags.Config, "config", "", "path to JSON file listing many hooks to generate instead of -src and -dst")
+	flag.BoolVar(&Flags.Check, "check", false, "don't write files, exit with non-zero code if generated files are out of date")
+	flag.BoolVar(&Flags.Diff, "diff", false, "don't write files, print unified diff for out of date generated files like -check")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] -src path/to/package.Interface -dst path/to/package[.Hook]\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "   or: %s [flags] -config hooks.json\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "   or: %s [flags] packages\n\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "Packages like ./... are scanned for interfaces marked with %s.\n", hookgen.Directive)
+		fmt.Fprintf(flag.CommandLine.Output(), "Words after it are flags without '-' like: safe dispatch=parallel, and also\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "name=HookName and dst=path/to/package. Flags of the command line are defaults.\n\n")
+		flag.PrintDefaults()
+	}
+}
+
+// optionFlags defines flags of options of the hook in fs, current values
+// are their defaults. Directives of hookgen.Directive use them too.
+func (this *TFlags) optionFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&this.Safe, "safe", this.Safe, "protect the list of hooked items, see -sync; -dispatch=async hook is always safe")
+	fs.StringVar(&this.Sync, "sync", this.Sync, "the way -safe hook protects its list: mutex, rwmutex or atomic")
+	fs.StringVar(&this.Formatter, "fmt", this.Formatter, "go pretty-printer: gofmt, goimports or noop")
+	fs.StringVar(&this.Results, "results", this.Results, "result strategy: first-error, all-errors, first, last or all; per method like: last,Close=all-errors")
+	fs.StringVar(&this.Dispatch, "dispatch", this.Dispatch, "the way to call hooked items: sequential, parallel or async")
+	fs.IntVar(&this.Concurrency, "concurrency", this.Concurrency, "max number of parallel calls with -dispatch=parallel, 0 is unlimited")
+	fs.IntVar(&this.QueueSize, "queue", this.QueueSize, "size of the queue of calls with -dispatch=async")
+	fs.StringVar(&this.Overflow, "overflow", this.Overflow, "policy for calls made when the queue is full: block, drop-newest or drop-oldest")
+	fs.StringVar(&this.Order, "order", this.Order, "order of calls of items with the same priority: fifo or lifo (reverse order of registration like defer)")
+	fs.BoolVar(&this.Once, "once", this.Once, "call every method of the hook only once, items appended later are called right away")
+	fs.BoolVar(&this.Recover, "recover", this.Recover, "recover panics of hooked items and report them to OnPanic field of the hook, other items are called anyway")
+	fs.BoolVar(&this.Funcs, "funcs", this.Funcs, "generate <Interface>Func adapter or <Interface>Funcs struct of functions to use functions as the interface")
+	fs.BoolVar(&this.Recorder, "recorder", this.Recorder, "generate <Interface>Recorder recording calls of the interface with their arguments for tests")
+	fs.BoolVar(&this.Tests, "tests", this.Tests, "generate tests of the hook into <file>_test.go next to it")
+	fs.StringVar(&this.Template, "template", this.Template, "path to text/template file used instead of the built-in template, it's executed with hookgen.HookTemplate and may use functions of hookgen.FuncMap")
 }
 
 func main() {
+	flag.Parse()
+
+	Flags.Patterns = flag.Args()
+
 	if Flags.ShowVersion {
 		fmt.Printf("hoog version: %s\n", Version)
 		os.Exit(0)
 	}
 
 	if err := Flags.Validate(); err != nil {
-		fmt.Printf("error: %s\n\n", err)
+		fmt.Fprintf(os.Stderr, "error: %s\n\n", err)
 		flag.Usage()
 		os.Exit(1)
 	}
 
-	b := bytes.Buffer{}
-	h := hookgen.Hookgen{
-		Src:       Flags.PathToSrc,
-		Dst:       Flags.PathToDst,
-		Safe:      Flags.Safe,
-		Formatter: Flags.Formatter,
+	jobs, ifaces, err := Flags.load()
+	if err != nil {
+		fatal(err)
+	}
+
+	if Flags.Check || Flags.Diff {
+		stale, err := check(os.Stdout, jobs, ifaces, Flags.Diff)
+		if err != nil {
+			fatal(err)
+		}
+
+		if stale > 0 {
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	for i := range jobs {
+		if err := jobs[i].Run(ifaces[i]); err != nil {
+			fatal(fmt.Errorf("%s: %w", jobs[i].Hookgen.Src, err))
+		}
 	}
+}
+
+// check reports generated files which differ from the existing ones to w,
+// or prints unified diff for them if showDiff is set, and returns the number
+// of them
+func check(w io.Writer, jobs []Job, ifaces []*hookgen.Interface, showDiff bool) (int, error) {
+	stale := 0
 
-	iface, err := loadInterface(resource.Package(Flags.PathToSrc), resource.Object(Flags.PathToSrc))
+	for i := range jobs {
+		filenames, d, err := jobs[i].Diff(ifaces[i])
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", jobs[i].Hookgen.Src, err)
+		}
+
+		stale += len(filenames)
+
+		if showDiff {
+			if _, err := w.Write(d); err != nil {
+				return 0, err
+			}
+
+			continue
+		}
+
+		for _, filename := range filenames {
+			fmt.Fprintf(w, "%s is out of date\n", filename)
+		}
+	}
+
+	return stale, nil
+}
+
+// load returns jobs and their source interfaces, they are found by
+// directives in Patterns or they are set by flags
+func (this *TFlags) load() ([]Job, []*hookgen.Interface, error) {
+	if len(this.Patterns) != 0 {
+		return this.discover()
+	}
+
+	jobs, err := this.Jobs()
 	if err != nil {
-		fatal(err)
+		return nil, nil, err
 	}
 
-	if err := h.Generate(&b, iface); err != nil {
-		fatal(err)
+	ifaces, err := load(jobs)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if err := os.MkdirAll(resource.Package(Flags.PathToDst), DirPermission); err != nil {
-		fatal(err)
+	return jobs, ifaces, nil
+}
+
+// Job generates one hook
+type Job struct {
+	Hookgen hookgen.Hookgen
+	// File is the name of generated file in the package of Hookgen.Dst
+	File string
+	// Dir is the directory of the package of Hookgen.Dst, it's set by load
+	Dir string
+	// Output is the path to generated file used instead of File and Dir if
+	// it isn't empty, Stdout writes the hook to stdout
+	Output string
+	// Tests enables generation of tests of the hook next to it
+	Tests bool
+}
+
+// Stdout is the value of Output to write generated hook to stdout
+const Stdout = "-"
+
+func (this *TFlags) Jobs() ([]Job, error) {
+	if this.Config != "" {
+		config, err := readConfig(this.Config)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs := make([]Job, 0, len(config.Hooks))
+		for i := range config.Hooks {
+			job := config.Hooks[i].Job()
+			if job.Hookgen.Template, err = readTemplate(config.Hooks[i].Template); err != nil {
+				return nil, err
+			}
+
+			jobs = append(jobs, job)
+		}
+
+		return jobs, nil
 	}
 
-	filename := filepath.Join(resource.Package(Flags.PathToDst), Flags.File)
-	if err := ioutil.WriteFile(filename, b.Bytes(), FilePermission); err != nil {
-		fatal(err)
+	job, err := this.Job()
+	if err != nil {
+		return nil, err
 	}
+
+	return []Job{job}, nil
 }
 
-func fatal(err error) {
-	fmt.Printf("error: %s\n", err)
-	os.Exit(1)
+// Job returns the job of options set by flags
+func (this *TFlags) Job() (Job, error) {
+	text, err := readTemplate(this.Template)
+	if err != nil {
+		return Job{}, err
+	}
+
+	return Job{
+		Hookgen: hookgen.Hookgen{
+			Src:         this.PathToSrc,
+			Dst:         this.PathToDst,
+			Safe:        this.Safe,
+			Sync:        this.Sync,
+			Results:     this.Results,
+			Dispatch:    this.Dispatch,
+			Concurrency: this.Concurrency,
+			QueueSize:   this.QueueSize,
+			Overflow:    this.Overflow,
+			Order:       this.Order,
+			Once:        this.Once,
+			Recover:     this.Recover,
+			Funcs:       this.Funcs,
+			Recorder:    this.Recorder,
+			Template:    text,
+			Formatter:   this.Formatter,
+		},
+		File:   this.File,
+		Output: this.Output,
+		Tests:  this.Tests,
+	}, nil
 }
 
-func loadInterface(dir, name string) (*types.Interface, error) {
-	ps, err := packages.Load(&packages.Config{
-		Mode: packages.NeedTypes,
-		Dir:  dir,
-	})
+// readTemplate returns the text of the template, it's empty if filename is
+func readTemplate(filename string) (string, error) {
+	if filename == "" {
+		return "", nil
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("template: %s", err)
+	}
+
+	return string(b), nil
+}
+
+// Filename returns the path to generated file
+func (this *Job) Filename() string {
+	if this.Output != "" {
+		return this.Output
+	}
+
+	return filepath.Join(this.Dir, this.File)
+}
+
+// TestFilename returns the path to generated tests of the hook
+func (this *Job) TestFilename() string {
+	return strings.TrimSuffix(this.Filename(), ".go") + "_test.go"
+}
+
+// generated is the content of generated file
+type generated struct {
+	Filename string
+	Source   []byte
+}
+
+// generate generates the hook and its tests if they are enabled
+func (this *Job) generate(iface *hookgen.Interface) ([]generated, error) {
+	b, err := this.Hookgen.GenerateInterface(iface)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(ps) == 0 {
-		return nil, fmt.Errorf("There are no packages in '%s'", dir)
+	files := []generated{{this.Filename(), b}}
+
+	if this.Tests {
+		b, err := this.Hookgen.GenerateInterfaceTests(iface)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, generated{this.TestFilename(), b})
+	}
+
+	return files, nil
+}
+
+// Run generates the hook and writes it into the file
+func (this *Job) Run(iface *hookgen.Interface) error {
+	files, err := this.generate(iface)
+	if err != nil {
+		return err
 	}
 
-	if len(ps) > 1 {
-		return nil, fmt.Errorf("Too many packages in '%s'", dir)
+	for _, file := range files {
+		if file.Filename == Stdout {
+			if _, err := os.Stdout.Write(file.Source); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(file.Filename), DirPermission); err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(file.Filename, file.Source, FilePermission); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Diff generates files in memory and returns names of the existing files
+// which differ from generated ones and unified diff between them, they are
+// empty if files are up to date. A missing file differs from anything.
+func (this *Job) Diff(iface *hookgen.Interface) ([]string, []byte, error) {
+	files, err := this.generate(iface)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if errs := ps[0].Errors; len(errs) != 0 {
-		errstr := make([]string, 0, len(errs))
+	filenames := []string{}
+	d := []byte{}
+
+	for _, file := range files {
+		old, err := ioutil.ReadFile(file.Filename)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, nil, err
+		}
 
-		for _, err := range errs {
-			errstr = append(errstr, err.Error())
+		if err == nil && bytes.Equal(old, file.Source) {
+			continue
 		}
 
-		return nil, fmt.Errorf("There are many errors: %s\n", strings.Join(errstr, "\n - "))
+		filenames = append(filenames, file.Filename)
+		d = append(d, diff.Unified(file.Filename, file.Filename+" (generated)", old, file.Source)...)
 	}
 
-	obj := ps[0].Types.Scope().Lookup(name)
-	if obj == nil {
-		return nil, fmt.Errorf("There is no interface with name '%s' in dir '%s'", name, dir)
+	return filenames, d, nil
+}
+
+// positioned is the error of hookgen with the position in the source
+type positioned interface {
+	error
+	Position() token.Position
+}
+
+// fatal prints the error and exits, errors with positions are printed like
+// "file:line:col: message" so editors can jump to them
+func fatal(err error) {
+	var lerr *hookgen.PackageLoadError
+	if errors.As(err, &lerr) {
+		for _, e := range lerr.Errors {
+			if e.Pos.IsValid() {
+				fmt.Fprintf(os.Stderr, "%s\n", e)
+			} else {
+				fmt.Fprintf(os.Stderr, "error: %s: %s\n", lerr.Pkg, e.Msg)
+			}
+		}
+
+		os.Exit(1)
 	}
 
-	if !types.IsInterface(obj.Type()) {
-		return nil, fmt.Errorf("'%s' is not an interface; it is '%s'", name, obj.Type().String())
+	var perr positioned
+	if errors.As(err, &perr) {
+		if pos := perr.Position(); pos.IsValid() {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", pos, perr)
+			os.Exit(1)
+		}
 	}
 
-	return obj.Type().Underlying().(*types.Interface), nil
+	fmt.Fprintf(os.Stderr, "error: %s\n", err)
+	os.Exit(1)
 }
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/axard/things/pkg/hookgen"
+)
+
+// diffJobs returns jobs writing hooks of iface into dir: the first file is up
+// to date, the second one is stale and the third one is missing
+func diffJobs(t *testing.T, dir string, iface *hookgen.Interface) []Job {
+	jobs := []Job{}
+
+	for _, name := range []string{"fresh.go", "stale.go", "missing.go"} {
+		jobs = append(jobs, Job{
+			Hookgen: hookgen.Hookgen{Dst: "github.com/axard/things/cmd/hookgen/testdata/events.Hook"},
+			Output:  filepath.Join(dir, name),
+		})
+	}
+
+	fresh, err := jobs[0].Hookgen.GenerateInterface(iface)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(jobs[0].Output, fresh, FilePermission); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(jobs[1].Output, []byte("package events\n"), FilePermission); err != nil {
+		t.Fatal(err)
+	}
+
+	return jobs
+}
+
+func TestJob_Diff(t *testing.T) {
+	iface, err := hookgen.Load("./testdata/events", "Listener")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jobs := diffJobs(t, t.TempDir(), iface)
+
+	tests := []struct {
+		name          string
+		job           Job
+		wantFilenames []string
+		wantDiff      []string
+	}{
+		{
+			name:          "Diff() returns nothing for file which is up to date",
+			job:           jobs[0],
+			wantFilenames: []string{},
+		},
+		{
+			name:          "Diff() returns stale file and diff with generated one",
+			job:           jobs[1],
+			wantFilenames: []string{jobs[1].Output},
+			wantDiff:      []string{"--- " + jobs[1].Output, "+++ " + jobs[1].Output + " (generated)", "+type Hook struct {"},
+		},
+		{
+			name:          "Diff() returns missing file",
+			job:           jobs[2],
+			wantFilenames: []string{jobs[2].Output},
+			wantDiff:      []string{"+package events"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filenames, d, err := tt.job.Diff(iface)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(filenames, tt.wantFilenames) {
+				t.Errorf("Job.Diff() filenames = %v, want %v", filenames, tt.wantFilenames)
+			}
+
+			if len(tt.wantDiff) == 0 && len(d) != 0 {
+				t.Errorf("Job.Diff() diff = %s, want none", d)
+			}
+
+			for _, want := range tt.wantDiff {
+				if !strings.Contains(string(d), want) {
+					t.Errorf("Job.Diff() diff = %s, want %v in it", d, want)
+				}
+			}
+		})
+	}
+}
+
+func Test_check(t *testing.T) {
+	iface, err := hookgen.Load("./testdata/events", "Listener")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jobs := diffJobs(t, t.TempDir(), iface)
+	ifaces := []*hookgen.Interface{iface, iface, iface}
+
+	tests := []struct {
+		name      string
+		jobs      []Job
+		showDiff  bool
+		wantStale int
+		wantW     []string
+	}{
+		{
+			name:      "check() reports nothing if files are up to date",
+			jobs:      jobs[:1],
+			wantStale: 0,
+		},
+		{
+			name:      "check() reports stale and missing files",
+			jobs:      jobs,
+			wantStale: 2,
+			wantW:     []string{jobs[1].Output + " is out of date\n", jobs[2].Output + " is out of date\n"},
+		},
+		{
+			name:      "check() prints diff of stale and missing files",
+			jobs:      jobs,
+			showDiff:  true,
+			wantStale: 2,
+			wantW:     []string{"--- " + jobs[1].Output, "--- " + jobs[2].Output},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+
+			stale, err := check(w, tt.jobs, ifaces[:len(tt.jobs)], tt.showDiff)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if stale != tt.wantStale {
+				t.Errorf("check() = %v, want %v", stale, tt.wantStale)
+			}
+
+			if len(tt.wantW) == 0 && w.Len() != 0 {
+				t.Errorf("check() w = %v, want nothing", w)
+			}
+
+			for _, want := range tt.wantW {
+				if !strings.Contains(w.String(), want) {
+					t.Errorf("check() w = %v, want %v in it", w, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package events
+
+import "fmt"
+
+// Notify notifies the listener
+func Notify(l Listener, e fmt.Stringer) {
+	l.Notify(e.String())
+}
@@ -0,0 +1,5 @@
+package events
+
+type Listener interface {
+	Notify(e string)
+}
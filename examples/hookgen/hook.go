@@ -5,46 +5,174 @@ package main
 
 import (
 	"sync"
+	"sync/atomic"
 )
 
+// Hook implements Action by calling every registered item. Methods call items
+// one by one in order of priority, items with the same priority in order of
+// registration. It's safe for concurrent use. Every method calls items only
+// once, items appended later are called with the arguments of that call right
+// away.
 type Hook struct {
-	list []*hooked
+	list []*hookedHook
 	m    sync.Mutex
+
+	fired  map[string]bool
+	replay []func(Action)
 }
 
-type hooked struct {
+type hookedHook struct {
 	Action
+	priority int
+	once     bool
+	fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook) Append(item Action) HookCancel {
+	return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in order of
+// registration
+func (this *Hook) AppendWithPriority(item Action, priority int) HookCancel {
+	return this.insert(item, priority, false, false)
 }
 
-type Cancel = func()
+// Prepend registers item to be called before all registered items
+func (this *Hook) Prepend(item Action) HookCancel {
+	return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook) AppendOnce(item Action) HookCancel {
+	return this.insert(item, 0, false, true)
+}
 
-func (this *Hook) Append(item Action) Cancel {
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook) insert(item Action, priority int, first, once bool) HookCancel {
 	this.m.Lock()
-	defer this.m.Unlock()
 
-	hooked := &hooked{item}
-	this.list = append(this.list, hooked)
+	list := this.list
+
+	i := 0
+	if first {
+		if len(list) != 0 {
+			priority = list[0].priority
+		}
+	} else {
+		for i < len(list) && list[i].priority >= priority {
+			i++
+		}
+	}
+
+	entry := &hookedHook{item, priority, once, 0}
+
+	rest := make([]*hookedHook, 0, len(list)+1)
+	rest = append(rest, list[:i]...)
+	rest = append(rest, entry)
+	rest = append(rest, list[i:]...)
+	this.list = rest
+
+	replay := this.replay
+	this.m.Unlock()
+
+	// calls made before the item is appended are replayed for it
+	for _, call := range replay {
+		if !this.claim(entry) {
+			break
+		}
+
+		call(item)
+	}
 
-	return func() { this.remove(hooked) }
+	return func() { this.remove(entry) }
 }
 
-func (this *Hook) remove(hooked *hooked) {
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook) remove(entry *hookedHook) {
 	this.m.Lock()
 	defer this.m.Unlock()
 
-	for i := range this.list {
-		if this.list[i] == hooked {
-			this.list = append(this.list[:i], this.list[i+1:]...)
+	list := this.list
+
+	for i := range list {
+		if list[i] == entry {
+			rest := make([]*hookedHook, 0, len(list)-1)
+			rest = append(rest, list[:i]...)
+			rest = append(rest, list[i+1:]...)
+			this.list = rest
 			break
 		}
 	}
 }
 
-func (this *Hook) Do() {
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook) claim(entry *hookedHook) bool {
+	if !entry.once {
+		return true
+	}
+
+	if !atomic.CompareAndSwapUint32(&entry.fired, 0, 1) {
+		return false
+	}
+
+	this.remove(entry)
+
+	return true
+}
+
+// record records the first call of the method to replay it for items
+// appended later, it returns the items to call or false if the method has
+// been called before
+func (this *Hook) record(method string, call func(Action)) ([]*hookedHook, bool) {
+	this.m.Lock()
+	defer this.m.Unlock()
+
+	if this.fired[method] {
+		return nil, false
+	}
+
+	if this.fired == nil {
+		this.fired = map[string]bool{}
+	}
+
+	this.fired[method] = true
+	this.replay = append(this.replay, call)
+
+	return this.list, true
+}
+
+// items returns the list of hooked items which is never changed in place
+func (this *Hook) items() []*hookedHook {
 	this.m.Lock()
 	defer this.m.Unlock()
 
-	for _, hooked := range this.list {
+	return this.list
+}
+
+// Do calls Do of every item.
+func (this *Hook) Do() {
+	list, ok := this.record("Do", func(item Action) {
+		item.Do()
+	})
+	if !ok {
+		return
+	}
+
+	for _, hooked := range list {
+		if !this.claim(hooked) {
+			continue
+		}
+
 		hooked.Do()
 	}
 }
@@ -15,6 +15,9 @@ const (
 	NumberOfAbortSignals = 2
 )
 
+//go:generate go run ../../cmd/hookgen .
+
+//hookgen:generate name=Hook once safe file=hook.go
 type Action interface {
 	Do()
 }
@@ -64,4 +67,7 @@ func main() {
 	}()
 
 	wg.Wait()
+
+	// the hook has been fired, so items appended later are called right away
+	hook.Append(ActionFunc(func() { fmt.Println() }))
 }
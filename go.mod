@@ -1,5 +1,10 @@
 module github.com/axard/things
 
-go 1.14
+go 1.26.0
 
-require golang.org/x/tools v0.0.0-20201125231158-b5590deeca9b
+require golang.org/x/tools v0.50.0
+
+require (
+	golang.org/x/mod v0.41.0 // indirect
+	golang.org/x/sync v0.23.0 // indirect
+)
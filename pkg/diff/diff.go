@@ -0,0 +1,187 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Context is the number of unchanged lines around changes in unified diff
+const Context = 3
+
+type op struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// Unified returns unified diff between a and b, it's empty if they are
+// equal
+func Unified(nameA, nameB string, a, b []byte) []byte {
+	if bytes.Equal(a, b) {
+		return nil
+	}
+
+	ops := edits(lines(a), lines(b))
+
+	out := bytes.Buffer{}
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", nameA, nameB)
+
+	for _, h := range hunks(ops) {
+		writeHunk(&out, ops, h)
+	}
+
+	return out.Bytes()
+}
+
+func lines(b []byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+
+	ls := strings.SplitAfter(string(b), "\n")
+	if ls[len(ls)-1] == "" {
+		ls = ls[:len(ls)-1]
+	}
+
+	return ls
+}
+
+// edits returns the shortest edit script turning a into b found by the
+// longest common subsequence
+func edits(a, b []string) []op {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	i, j := 0, 0
+
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{' ', a[i]})
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, op{'+', b[j]})
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		ops = append(ops, op{'-', a[i]})
+	}
+
+	for ; j < len(b); j++ {
+		ops = append(ops, op{'+', b[j]})
+	}
+
+	return ops
+}
+
+// hunk is the range of ops [begin, end)
+type hunk struct {
+	begin, end int
+}
+
+func hunks(ops []op) []hunk {
+	hs := []hunk{}
+
+	for i := 0; i < len(ops); i++ {
+		if ops[i].kind == ' ' {
+			continue
+		}
+
+		begin := i - Context
+		if begin < 0 {
+			begin = 0
+		}
+
+		end := i + 1
+		for end < len(ops) {
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+
+			if next == len(ops) || next-end > 2*Context {
+				break
+			}
+
+			end = next + 1
+		}
+
+		i = end - 1
+
+		end += Context
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		hs = append(hs, hunk{begin, end})
+	}
+
+	return hs
+}
+
+func writeHunk(out *bytes.Buffer, ops []op, h hunk) {
+	startA, startB := 1, 1
+	for _, o := range ops[:h.begin] {
+		if o.kind != '+' {
+			startA++
+		}
+
+		if o.kind != '-' {
+			startB++
+		}
+	}
+
+	lenA, lenB := 0, 0
+	for _, o := range ops[h.begin:h.end] {
+		if o.kind != '+' {
+			lenA++
+		}
+
+		if o.kind != '-' {
+			lenB++
+		}
+	}
+
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(startA, lenA), hunkRange(startB, lenB))
+
+	for _, o := range ops[h.begin:h.end] {
+		out.WriteByte(o.kind)
+		out.WriteString(o.line)
+
+		if !strings.HasSuffix(o.line, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(start, length int) string {
+	switch length {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprintf("%d", start)
+	default:
+		return fmt.Sprintf("%d,%d", start, length)
+	}
+}
@@ -0,0 +1,100 @@
+package diff
+
+import (
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	type args struct {
+		a string
+		b string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "Unified() returns nothing if texts are equal",
+			args: args{
+				a: "a\nb\n",
+				b: "a\nb\n",
+			},
+			want: "",
+		},
+		{
+			name: "Unified() returns one hunk with context",
+			args: args{
+				a: "1\n2\n3\n4\n5\n6\n7\n8\n",
+				b: "1\n2\n3\n4\nfive\n6\n7\n8\n",
+			},
+			want: `--- a
++++ b
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`,
+		},
+		{
+			name: "Unified() returns separate hunks for distant changes",
+			args: args{
+				a: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+				b: "one\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			},
+			want: `--- a
++++ b
+@@ -1,4 +1,4 @@
+-1
++one
+ 2
+ 3
+ 4
+@@ -7,4 +7,3 @@
+ 7
+ 8
+ 9
+-10
+`,
+		},
+		{
+			name: "Unified() returns diff for new file",
+			args: args{
+				a: "",
+				b: "a\n",
+			},
+			want: `--- a
++++ b
+@@ -0,0 +1 @@
++a
+`,
+		},
+		{
+			name: "Unified() marks missing newline at end of file",
+			args: args{
+				a: "a\nb",
+				b: "a\nb\n",
+			},
+			want: `--- a
++++ b
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++b
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(Unified("a", "b", []byte(tt.args.a), []byte(tt.args.b))); got != tt.want {
+				t.Errorf("Unified() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
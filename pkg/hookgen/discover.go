@@ -0,0 +1,120 @@
+package hookgen
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Directive marks interfaces to generate hooks for, words after it are
+// options of the hook like:
+//
+//	//hookgen:generate name=ShutdownHook safe dispatch=parallel
+//	type Shutdowner interface {
+//	    Shutdown(ctx context.Context) error
+//	}
+const Directive = "//hookgen:generate"
+
+// Annotation is the interface marked with Directive, the interface marked
+// many times gives many annotations
+type Annotation struct {
+	Interface *Interface
+	// Args are words of the directive after its name
+	Args []string
+	// Pos is the position of the directive
+	Pos token.Position
+}
+
+// Discover loads packages matching patterns like "./..." and finds
+// interfaces marked with Directive in them. Annotations are sorted by their
+// positions. Only packages with directives must be loaded without errors.
+func Discover(patterns ...string) ([]Annotation, error) {
+	ps, err := packages.Load(&packages.Config{Mode: loadMode}, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := []Annotation{}
+
+	for _, p := range ps {
+		for _, d := range directives(p.Syntax) {
+			iface, err := LookupInterface(p, d.name)
+			if err != nil {
+				return nil, err
+			}
+
+			annotations = append(annotations, Annotation{
+				Interface: iface,
+				Args:      d.args,
+				Pos:       p.Fset.Position(d.pos),
+			})
+		}
+	}
+
+	sort.SliceStable(annotations, func(i, j int) bool {
+		a, b := annotations[i].Pos, annotations[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+
+		return a.Offset < b.Offset
+	})
+
+	return annotations, nil
+}
+
+// directive is Directive found in the doc comment of the type
+type directive struct {
+	name string
+	args []string
+	pos  token.Pos
+}
+
+// directives finds Directive in doc comments of types declared in files
+func directives(files []*ast.File) []directive {
+	found := []directive{}
+
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+
+				doc := typeDoc(gen, ts)
+				if doc == nil {
+					continue
+				}
+
+				for _, c := range doc.List {
+					if args, ok := directiveArgs(c.Text); ok {
+						found = append(found, directive{ts.Name.Name, args, c.Pos()})
+					}
+				}
+			}
+		}
+	}
+
+	return found
+}
+
+// directiveArgs returns words of the comment after Directive, ok is false
+// if the comment isn't Directive
+func directiveArgs(comment string) ([]string, bool) {
+	if !strings.HasPrefix(comment, Directive) {
+		return nil, false
+	}
+
+	rest := comment[len(Directive):]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return nil, false
+	}
+
+	return strings.Fields(rest), true
+}
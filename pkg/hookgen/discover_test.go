@@ -0,0 +1,108 @@
+package hookgen
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+const directivesSource = `package events
+
+// Listener listens events.
+//
+//hookgen:generate name=ListenerHook safe
+//hookgen:generate dst=../other
+type Listener interface {
+	Notify(e string)
+}
+
+type (
+	//hookgen:generate dispatch=parallel
+	Closer interface {
+		Close() error
+	}
+
+	//hookgen:generated
+	Other interface{}
+)
+
+//hookgen:generate
+var v int
+`
+
+func Test_directives(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "events.go", directivesSource, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type found struct {
+		Name string
+		Args []string
+	}
+
+	want := []found{
+		{"Listener", []string{"name=ListenerHook", "safe"}},
+		{"Listener", []string{"dst=../other"}},
+		{"Closer", []string{"dispatch=parallel"}},
+	}
+
+	got := []found{}
+	for _, d := range directives([]*ast.File{f}) {
+		got = append(got, found{d.name, d.args})
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("directives() = %v, want %v", got, want)
+	}
+}
+
+func Test_directiveArgs(t *testing.T) {
+	type args struct {
+		comment string
+	}
+	tests := []struct {
+		name   string
+		args   args
+		want   []string
+		wantOk bool
+	}{
+		{
+			name:   "directive with options",
+			args:   args{comment: "//hookgen:generate name=Hook  safe"},
+			want:   []string{"name=Hook", "safe"},
+			wantOk: true,
+		},
+		{
+			name:   "directive without options",
+			args:   args{comment: "//hookgen:generate"},
+			want:   []string{},
+			wantOk: true,
+		},
+		{
+			name:   "other directive",
+			args:   args{comment: "//hookgen:generated"},
+			want:   nil,
+			wantOk: false,
+		},
+		{
+			name:   "directive with space after slashes",
+			args:   args{comment: "// hookgen:generate safe"},
+			want:   nil,
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := directiveArgs(tt.args.comment)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("directiveArgs() got = %v, want %v", got, tt.want)
+			}
+			if ok != tt.wantOk {
+				t.Errorf("directiveArgs() ok = %v, want %v", ok, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package hookgen
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// Docs are doc comments of the source interface and its methods, the hook
+// and its methods carry them
+type Docs struct {
+	Interface string
+	// Methods maps names of methods to their comments
+	Methods map[string]string
+}
+
+// InterfaceDocs finds comments of the interface with the name in files of
+// its package, methods of embedded interfaces declared there get their
+// comments too. Text of comments is without comment markers.
+func InterfaceDocs(files []*ast.File, name string) Docs {
+	docs := Docs{Methods: map[string]string{}}
+
+	specs := map[string]*ast.TypeSpec{}
+	specDocs := map[string]*ast.CommentGroup{}
+
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+
+			for _, spec := range gen.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok {
+					specs[ts.Name.Name] = ts
+					specDocs[ts.Name.Name] = typeDoc(gen, ts)
+				}
+			}
+		}
+	}
+
+	spec := specs[name]
+	if spec == nil {
+		return docs
+	}
+
+	docs.Interface = text(specDocs[name])
+
+	visited := map[string]bool{}
+
+	var walk func(spec *ast.TypeSpec)
+	walk = func(spec *ast.TypeSpec) {
+		if visited[spec.Name.Name] {
+			return
+		}
+
+		visited[spec.Name.Name] = true
+
+		iface, ok := spec.Type.(*ast.InterfaceType)
+		if !ok {
+			return
+		}
+
+		for _, field := range iface.Methods.List {
+			if len(field.Names) == 0 {
+				if ident, ok := field.Type.(*ast.Ident); ok && specs[ident.Name] != nil {
+					walk(specs[ident.Name])
+				}
+
+				continue
+			}
+
+			doc := field.Doc
+			if doc == nil {
+				doc = field.Comment
+			}
+
+			for _, n := range field.Names {
+				if _, ok := docs.Methods[n.Name]; !ok {
+					docs.Methods[n.Name] = text(doc)
+				}
+			}
+		}
+	}
+
+	walk(spec)
+
+	return docs
+}
+
+// typeDoc returns the doc comment of the type spec in the declaration, the
+// doc of the single spec is the doc of its declaration
+func typeDoc(gen *ast.GenDecl, ts *ast.TypeSpec) *ast.CommentGroup {
+	if ts.Doc == nil && len(gen.Specs) == 1 {
+		return gen.Doc
+	}
+
+	return ts.Doc
+}
+
+func text(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(doc.Text())
+}
@@ -0,0 +1,83 @@
+package hookgen
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+const docsSource = `package events
+
+// Listener listens events.
+type Listener interface {
+	// Start is called first.
+	Start()
+	Stop() // Stop is called last.
+	Notifier
+	Flush()
+}
+
+type (
+	// Notifier notifies.
+	Notifier interface {
+		// Notify is called on event.
+		Notify(e string)
+	}
+
+	Other interface{}
+)
+`
+
+func TestInterfaceDocs(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "events.go", docsSource, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type args struct {
+		name string
+	}
+	tests := []struct {
+		name string
+		args args
+		want Docs
+	}{
+		{
+			name: "docs of interface and methods of embedded interface",
+			args: args{name: "Listener"},
+			want: Docs{
+				Interface: "Listener listens events.",
+				Methods: map[string]string{
+					"Start":  "Start is called first.",
+					"Stop":   "Stop is called last.",
+					"Notify": "Notify is called on event.",
+					"Flush":  "",
+				},
+			},
+		},
+		{
+			name: "doc of interface in grouped declaration",
+			args: args{name: "Notifier"},
+			want: Docs{
+				Interface: "Notifier notifies.",
+				Methods: map[string]string{
+					"Notify": "Notify is called on event.",
+				},
+			},
+		},
+		{
+			name: "missing interface has no docs",
+			args: args{name: "Missing"},
+			want: Docs{Methods: map[string]string{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InterfaceDocs([]*ast.File{f}, tt.args.name); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InterfaceDocs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
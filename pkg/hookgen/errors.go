@@ -0,0 +1,177 @@
+package hookgen
+
+import (
+	"fmt"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// NotFoundError is returned if there is no package matching the pattern or
+// no interface with the name in the package
+type NotFoundError struct {
+	// Pkg is the pattern of the package or its import path
+	Pkg string
+	// Name is the name of the interface, it's empty if the package isn't
+	// found
+	Name string
+}
+
+func (this *NotFoundError) Error() string {
+	if this.Name == "" {
+		return fmt.Sprintf("there are no packages in '%s'", this.Pkg)
+	}
+
+	return fmt.Sprintf("there is no interface with name '%s' in '%s'", this.Name, this.Pkg)
+}
+
+// NotInterfaceError is returned if the type can't be hooked because it isn't
+// an interface or it's an interface with type constraints
+type NotInterfaceError struct {
+	Name string
+	// Type is the type of the declaration
+	Type string
+	// Constraints is true if the type is an interface with type
+	// constraints
+	Constraints bool
+	// Pos is the position of the declaration, it's zero if it's unknown
+	Pos token.Position
+}
+
+func (this *NotInterfaceError) Error() string {
+	if this.Constraints {
+		return fmt.Sprintf("interface '%s' has type constraints, it can't be hooked", this.Name)
+	}
+
+	return fmt.Sprintf("'%s' is not an interface; it is '%s'", this.Name, this.Type)
+}
+
+// Position returns the position of the declaration
+func (this *NotInterfaceError) Position() token.Position {
+	return this.Pos
+}
+
+// UnsupportedSignatureError is returned if the method of the interface
+// can't be hooked with options of the generator
+type UnsupportedSignatureError struct {
+	Method string
+	// Reason tells what is wrong with the method like "conflicts with the
+	// method of hook"
+	Reason string
+	// Pos is the position of the method, it's zero if it's unknown
+	Pos token.Position
+}
+
+func (this *UnsupportedSignatureError) Error() string {
+	return fmt.Sprintf("method '%s' %s", this.Method, this.Reason)
+}
+
+// Position returns the position of the method
+func (this *UnsupportedSignatureError) Position() token.Position {
+	return this.Pos
+}
+
+// PackageLoadError is returned if the package can't be loaded or it has
+// errors, every error has its own position
+type PackageLoadError struct {
+	Pkg    string
+	Errors []PositionError
+}
+
+func (this *PackageLoadError) Error() string {
+	errs := make([]string, 0, len(this.Errors))
+	for _, err := range this.Errors {
+		errs = append(errs, err.Error())
+	}
+
+	return fmt.Sprintf("package '%s' has errors:\n%s", this.Pkg, strings.Join(errs, "\n"))
+}
+
+// PositionError is the error at the position in the source
+type PositionError struct {
+	// Pos is zero if the position is unknown
+	Pos token.Position
+	Msg string
+}
+
+func (this PositionError) Error() string {
+	if !this.Pos.IsValid() {
+		return this.Msg
+	}
+
+	return this.Pos.String() + ": " + this.Msg
+}
+
+// Position returns the position of the error
+func (this PositionError) Position() token.Position {
+	return this.Pos
+}
+
+// newPackageLoadError converts errors of the loaded package
+func newPackageLoadError(p *packages.Package) *PackageLoadError {
+	err := &PackageLoadError{Pkg: p.PkgPath}
+	if err.Pkg == "" {
+		err.Pkg = p.ID
+	}
+
+	for _, e := range p.Errors {
+		// output of the compiler reported by go list repeats type errors
+		if e.Kind == packages.ListError && strings.HasPrefix(e.Msg, "# ") && hasTypeErrors(p) {
+			continue
+		}
+
+		err.Errors = append(err.Errors, PositionError{
+			Pos: parsePosition(e.Pos),
+			Msg: e.Msg,
+		})
+	}
+
+	return err
+}
+
+func hasTypeErrors(p *packages.Package) bool {
+	for _, e := range p.Errors {
+		if e.Kind == packages.TypeError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parsePosition parses the position like "file:line:col" or "file:line",
+// it's zero for "" and "-"
+func parsePosition(s string) token.Position {
+	pos := token.Position{}
+
+	// the name of file may have colons, so numbers are taken from the end
+	for _, n := range []*int{&pos.Column, &pos.Line} {
+		i := strings.LastIndex(s, ":")
+		if i < 0 {
+			break
+		}
+
+		v, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			break
+		}
+
+		*n = v
+		s = s[:i]
+	}
+
+	if pos.Line == 0 {
+		// "file:line" is parsed as column only
+		pos.Line, pos.Column = pos.Column, 0
+	}
+
+	if pos.Line == 0 || s == "" || s == "-" {
+		return token.Position{}
+	}
+
+	pos.Filename = s
+
+	return pos
+}
@@ -0,0 +1,144 @@
+package hookgen
+
+import (
+	"errors"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_parsePosition(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		name string
+		args args
+		want token.Position
+	}{
+		{
+			name: "file, line and column",
+			args: args{s: "/path/to/file.go:12:3"},
+			want: token.Position{Filename: "/path/to/file.go", Line: 12, Column: 3},
+		},
+		{
+			name: "file and line",
+			args: args{s: "/path/to/file.go:12"},
+			want: token.Position{Filename: "/path/to/file.go", Line: 12},
+		},
+		{
+			name: "file with colon",
+			args: args{s: "C:/file.go:12:3"},
+			want: token.Position{Filename: "C:/file.go", Line: 12, Column: 3},
+		},
+		{
+			name: "unknown position",
+			args: args{s: "-"},
+			want: token.Position{},
+		},
+		{
+			name: "empty position",
+			args: args{s: ""},
+			want: token.Position{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parsePosition(tt.args.s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePosition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_errors(t *testing.T) {
+	_, err := Load("./internal/instance", "Missing")
+
+	var nferr *NotFoundError
+	if !errors.As(err, &nferr) || nferr.Name != "Missing" {
+		t.Errorf("Load() error = %v, want NotFoundError of 'Missing'", err)
+	}
+
+	_, err = Load("./internal/instance", "Struct")
+
+	var nierr *NotInterfaceError
+	if !errors.As(err, &nierr) {
+		t.Fatalf("Load() error = %v, want NotInterfaceError", err)
+	}
+
+	if pos := nierr.Position(); filepath.Base(pos.Filename) != "instance.go" || pos.Line == 0 {
+		t.Errorf("NotInterfaceError.Position() = %v, want position in instance.go", pos)
+	}
+}
+
+func TestHookgen_GenerateInterface_errors(t *testing.T) {
+	iface, err := Load("./internal/instance", "Interface5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		fields     Hookgen
+		wantMethod string
+	}{
+		{
+			name: "result strategy needs error",
+			fields: Hookgen{
+				Dst:     "github.com/axard/things/pkg/hookgen/internal/instance.Hook",
+				Results: ResultsFirstError,
+			},
+			wantMethod: "Check",
+		},
+		{
+			name: "once hook can't return results",
+			fields: Hookgen{
+				Dst:  "github.com/axard/things/pkg/hookgen/internal/instance.Hook",
+				Once: true,
+			},
+			wantMethod: "Check",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			this := tt.fields
+
+			_, err := this.GenerateInterface(iface)
+
+			var serr *UnsupportedSignatureError
+			if !errors.As(err, &serr) {
+				t.Fatalf("Hookgen.GenerateInterface() error = %v, want UnsupportedSignatureError", err)
+			}
+
+			if serr.Method != tt.wantMethod {
+				t.Errorf("UnsupportedSignatureError.Method = %v, want %v", serr.Method, tt.wantMethod)
+			}
+
+			for _, m := range iface.Methods {
+				if m.Name == tt.wantMethod && serr.Position() != m.Pos {
+					t.Errorf("UnsupportedSignatureError.Position() = %v, want %v", serr.Position(), m.Pos)
+				}
+			}
+		})
+	}
+}
+
+func TestHookgen_GenerateInterface_typeParamResult(t *testing.T) {
+	iface, err := Load("./internal/instance", "Interface13")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	this := &Hookgen{
+		Dst:     "github.com/axard/things/pkg/hookgen/internal/instance.Hook",
+		Results: ResultsFirst,
+	}
+
+	_, err = this.GenerateInterface(iface)
+
+	var serr *UnsupportedSignatureError
+	if !errors.As(err, &serr) || serr.Method != "Get" {
+		t.Errorf("Hookgen.GenerateInterface() error = %v, want UnsupportedSignatureError of 'Get'", err)
+	}
+}
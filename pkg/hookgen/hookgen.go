@@ -2,39 +2,387 @@ package hookgen
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"go/token"
 	"go/types"
 	"io"
 	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/axard/things/pkg/formatter"
 	"github.com/axard/things/pkg/resource"
 )
 
+// Ways to call hooked items.
+const (
+	// DispatchSequential calls items one by one in order of registration.
+	DispatchSequential = "sequential"
+	// DispatchParallel calls every item in its own goroutine and waits for
+	// all of them.
+	DispatchParallel = "parallel"
+	// DispatchAsync queues calls and returns immediately, a worker
+	// goroutine calls items one by one.
+	DispatchAsync = "async"
+)
+
+// Policies of async hook for calls made when its queue is full.
+const (
+	// OverflowBlock waits for free space in the queue.
+	OverflowBlock = "block"
+	// OverflowDropNewest drops the call being made.
+	OverflowDropNewest = "drop-newest"
+	// OverflowDropOldest drops the oldest queued call.
+	OverflowDropOldest = "drop-oldest"
+)
+
+// Ways the safe hook protects its list of items, the list is never changed
+// in place so items are called without holding locks.
+const (
+	// SyncMutex protects the list with sync.Mutex.
+	SyncMutex = "mutex"
+	// SyncRWMutex protects the list with sync.RWMutex.
+	SyncRWMutex = "rwmutex"
+	// SyncAtomic keeps the list in atomic.Value.
+	SyncAtomic = "atomic"
+)
+
+// Orders of calls of items with the same priority.
+const (
+	// OrderFIFO calls items in order of registration.
+	OrderFIFO = "fifo"
+	// OrderLIFO calls items in reverse order of registration like defer.
+	OrderLIFO = "lifo"
+)
+
+// DefaultQueueSize is the size of the queue of async hook if it's not set.
+const DefaultQueueSize = 64
+
 type Hookgen struct {
 	Src string
 	Dst string
+	// Package is the name of the package of Dst if it exists, the name of
+	// new package is made of the last element of Dst
+	Package string
 
+	// Safe protects the list of items, async hook is always safe since its
+	// worker reads the list while the caller changes it
 	Safe bool
+	// Sync is SyncMutex (default), SyncRWMutex or SyncAtomic, it is used
+	// only with Safe
+	Sync string
+
+	// Results selects result strategies like "last,Close=all-errors",
+	// strategy without method name is used for the rest of methods
+	Results string
+
+	// Dispatch is DispatchSequential (default), DispatchParallel or
+	// DispatchAsync, Concurrency limits the number of parallel calls if
+	// it isn't zero
+	Dispatch    string
+	Concurrency int
+
+	// QueueSize and Overflow set up the queue of async hook
+	QueueSize int
+	Overflow  string
+
+	// Order is OrderFIFO (default) or OrderLIFO, it's the order of calls of
+	// items with the same priority
+	Order string
+
+	// Once makes every method of the hook run only once, items appended
+	// later are called right away with the arguments of that call
+	Once bool
+
+	// Recover makes the hook recover panics of items and report them to
+	// its OnPanic field, other items are called anyway
+	Recover bool
+
+	// Funcs makes hookgen generate <Interface>Func adapter for interface
+	// with one method and <Interface>Funcs struct of On<Method> functions
+	// for interface with many methods
+	Funcs bool
+
+	// Recorder makes hookgen generate <Interface>Recorder which records
+	// calls of the interface with their arguments, it's useful in tests
+	Recorder bool
+
+	// Docs are comments of the source interface, the hook and its methods
+	// carry them
+	Docs Docs
+
+	// Template is the text of template used instead of the built-in one,
+	// it's executed with HookTemplate, see HookTemplate.Execute
+	Template string
 
 	Formatter string
+
+	imports *imports
+
+	// pos and methodPos are positions of the source interface and its
+	// methods in errors, they are known for the loaded interface only
+	pos       token.Position
+	methodPos map[string]token.Position
+}
+
+// reservedNames are names of variables and fields of the hook and its tests,
+// parameters with these names are renamed
+var reservedNames = map[string]bool{
+	"this":     true,
+	"list":     true,
+	"hooked":   true,
+	"errs":     true,
+	"err":      true,
+	"results":  true,
+	"result":   true,
+	"wg":       true,
+	"sem":      true,
+	"started":  true,
+	"ctxErr":   true,
+	"i":        true,
+	"dispatch": true,
+	"item":     true,
+	"ok":       true,
+	"hook":     true,
 }
 
 func (this *Hookgen) Generate(w io.Writer, i *types.Interface) error {
-	ht := &HookTemplate{
-		Imports:        this.methodImports(i),
-		InterfaceName:  this.interfaceName(resource.Object(this.Src)),
-		HookName:       this.hookName(resource.Object(this.Dst)),
-		PackageName:    this.packageName(i.Method(0).Pkg().Name()),
-		MethodName:     this.methodName(i),
-		MethodDeclArgs: this.methodDeclArgs(i),
-		MethodCallArgs: this.methodCallArgs(i),
+	return this.fresh().generate(w, i, nil)
+}
+
+// GenerateType generates hook for interface type t. Generic interface gives
+// generic hook with the same type parameters, instantiated one gives hook
+// for its type arguments.
+func (this *Hookgen) GenerateType(w io.Writer, t types.Type) error {
+	i, named, err := this.interfaceType(t)
+	if err != nil {
+		return err
+	}
+
+	return this.fresh().generate(w, i, named)
+}
+
+// GenerateTests generates tests of the hook generated by GenerateType for
+// t, they check the order of calls, cancellation of items and concurrent use
+// of safe hook. Tests of generic hook can't be generated, t must be
+// instantiated.
+func (this *Hookgen) GenerateTests(w io.Writer, t types.Type) error {
+	i, named, err := this.interfaceType(t)
+	if err != nil {
+		return err
+	}
+
+	ht, err := this.fresh().hookTemplate(i, named)
+	if err != nil {
+		return err
+	}
+
+	if ht.TypeParams != "" {
+		return fmt.Errorf("tests of generic hook '%s' can't be generated, instantiate it", ht.HookName)
+	}
+
+	return this.write(w, ht.WriteTests)
+}
 
-		Safe: this.Safe,
+// GenerateInterface generates the hook for the loaded interface and
+// returns its source, Src and Docs are taken from iface if they aren't set.
+func (this *Hookgen) GenerateInterface(iface *Interface) ([]byte, error) {
+	b := bytes.Buffer{}
+	if err := this.withInterface(iface).GenerateType(&b, iface.Type); err != nil {
+		return nil, err
 	}
 
+	return b.Bytes(), nil
+}
+
+// GenerateInterfaceTests generates tests of the hook for the loaded
+// interface like GenerateInterface.
+func (this *Hookgen) GenerateInterfaceTests(iface *Interface) ([]byte, error) {
+	b := bytes.Buffer{}
+	if err := this.withInterface(iface).GenerateTests(&b, iface.Type); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// withInterface returns the copy of the generator with Src and Docs of
+// iface if they aren't set
+func (this *Hookgen) withInterface(iface *Interface) *Hookgen {
+	h := *this
+
+	if h.Src == "" {
+		h.Src = iface.Src()
+	}
+
+	if h.Docs.Interface == "" && len(h.Docs.Methods) == 0 {
+		h.Docs = iface.Docs
+	}
+
+	h.pos = iface.Pos
+	h.methodPos = map[string]token.Position{}
+
+	for _, m := range iface.Methods {
+		h.methodPos[m.Name] = m.Pos
+	}
+
+	return &h
+}
+
+// fresh returns the copy of the generator for one call generating the hook,
+// so calls don't share imports of the hook and may run concurrently
+func (this *Hookgen) fresh() *Hookgen {
+	h := *this
+	h.imports = nil
+
+	return &h
+}
+
+func (this *Hookgen) interfaceType(t types.Type) (*types.Interface, *types.Named, error) {
+	i, ok := t.Underlying().(*types.Interface)
+	if !ok {
+		return nil, nil, &NotInterfaceError{
+			Name: resource.Object(this.Src),
+			Type: t.String(),
+			Pos:  this.pos,
+		}
+	}
+
+	named, _ := types.Unalias(t).(*types.Named)
+
+	return i, named, nil
+}
+
+func (this *Hookgen) generate(w io.Writer, i *types.Interface, named *types.Named) error {
+	ht, err := this.hookTemplate(i, named)
+	if err != nil {
+		return err
+	}
+
+	if this.Template != "" {
+		return this.write(w, func(w io.Writer) error {
+			return ht.Execute(w, this.Template)
+		})
+	}
+
+	return this.write(w, ht.Write)
+}
+
+// hookTemplate checks options and collects data of the hook for templates
+func (this *Hookgen) hookTemplate(i *types.Interface, named *types.Named) (*HookTemplate, error) {
+	if !i.IsMethodSet() {
+		return nil, &NotInterfaceError{
+			Name:        resource.Object(this.Src),
+			Type:        i.String(),
+			Constraints: true,
+			Pos:         this.pos,
+		}
+	}
+
+	srcPkgPath, srcPkgName := this.srcPackage(i)
+
+	locals := []string{resource.Package(this.Dst)}
+	if resource.Package(this.Src) == resource.Package(this.Dst) {
+		locals = append(locals, srcPkgPath)
+	}
+
+	// imports are collected before methods, so parameters can be renamed
+	// if they clash with them
+	this.imports = newImports(locals...)
+	this.methodImports(i, this.typeImports(named)...)
+
+	dispatch, err := this.dispatch()
+	if err != nil {
+		return nil, err
+	}
+
+	sync, err := this.sync()
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := this.order()
+	if err != nil {
+		return nil, err
+	}
+
+	methods, err := this.methods(i)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := this.checkMethods(methods); err != nil {
+		return nil, err
+	}
+
+	if this.Once {
+		if err := this.checkOnce(methods); err != nil {
+			return nil, err
+		}
+	}
+
+	if this.Funcs {
+		if err := this.checkFuncs(methods); err != nil {
+			return nil, err
+		}
+	}
+
+	if this.Recorder {
+		if err := this.checkRecorder(methods); err != nil {
+			return nil, err
+		}
+	}
+
+	overflow := ""
+	if dispatch == DispatchAsync {
+		if overflow, err = this.overflow(); err != nil {
+			return nil, err
+		}
+
+		if err := this.checkAsync(methods); err != nil {
+			return nil, err
+		}
+	}
+
+	typeParams, typeArgs := this.typeParams(named)
+
+	ht := &HookTemplate{
+		InterfaceName: this.interfaceName(i, resource.Object(this.Src)) + this.interfaceArgs(named),
+		HookName:      this.hookName(resource.Object(this.Dst)),
+		PackageName:   this.packageName(srcPkgName),
+		Doc:           this.Docs.Interface,
+		Methods:       methods,
+
+		TypeParams: typeParams,
+		TypeArgs:   typeArgs,
+
+		Safe: this.Safe || dispatch == DispatchAsync,
+		Sync: sync,
+
+		Dispatch:    dispatch,
+		Concurrency: this.Concurrency,
+		QueueSize:   this.queueSize(),
+		Overflow:    overflow,
+
+		Order:    order,
+		Once:     this.Once,
+		Recover:  this.Recover,
+		Funcs:    this.Funcs,
+		Recorder: this.Recorder,
+	}
+
+	ht.Imports = this.importer().list()
+
+	return ht, nil
+}
+
+// write formats the output of the template and writes it to w
+func (this *Hookgen) write(w io.Writer, execute func(w io.Writer) error) error {
 	buf := bytes.Buffer{}
-	if err := ht.Write(&buf); err != nil {
+	if err := execute(&buf); err != nil {
 		return err
 	}
 
@@ -52,6 +400,173 @@ func (this *Hookgen) Generate(w io.Writer, i *types.Interface) error {
 	return nil
 }
 
+func (this *Hookgen) sync() (string, error) {
+	switch this.Sync {
+	case "", SyncMutex:
+		return SyncMutex, nil
+	case SyncRWMutex, SyncAtomic:
+		return this.Sync, nil
+	default:
+		return "", fmt.Errorf("unknown sync '%s'", this.Sync)
+	}
+}
+
+func (this *Hookgen) dispatch() (string, error) {
+	if this.Concurrency < 0 {
+		return "", fmt.Errorf("concurrency can't be negative: %d", this.Concurrency)
+	}
+
+	dispatch := ""
+
+	switch this.Dispatch {
+	case "", DispatchSequential:
+		dispatch = DispatchSequential
+	case DispatchParallel, DispatchAsync:
+		dispatch = this.Dispatch
+	default:
+		return "", fmt.Errorf("unknown dispatch '%s'", this.Dispatch)
+	}
+
+	if this.Concurrency > 0 && dispatch != DispatchParallel {
+		return "", fmt.Errorf("concurrency is used only with dispatch '%s', not '%s'", DispatchParallel, dispatch)
+	}
+
+	return dispatch, nil
+}
+
+func (this *Hookgen) overflow() (string, error) {
+	switch this.Overflow {
+	case "", OverflowBlock:
+		return OverflowBlock, nil
+	case OverflowDropNewest, OverflowDropOldest:
+		return this.Overflow, nil
+	default:
+		return "", fmt.Errorf("unknown overflow policy '%s'", this.Overflow)
+	}
+}
+
+func (this *Hookgen) order() (string, error) {
+	switch this.Order {
+	case "", OrderFIFO:
+		return OrderFIFO, nil
+	case OrderLIFO:
+		return OrderLIFO, nil
+	default:
+		return "", fmt.Errorf("unknown order '%s'", this.Order)
+	}
+}
+
+func (this *Hookgen) queueSize() int {
+	if this.QueueSize <= 0 {
+		return DefaultQueueSize
+	}
+
+	return this.QueueSize
+}
+
+// checkMethods checks methods don't conflict with methods of the hook
+func (this *Hookgen) checkMethods(methods []Method) error {
+	for _, m := range methods {
+		switch m.Name {
+		case "Append", "AppendWithPriority", "Prepend", "AppendOnce":
+			return this.unsupported(m.Name, "conflicts with the method of hook")
+		}
+
+		if this.Recover && m.Name == "OnPanic" {
+			return this.unsupported(m.Name, "conflicts with the field of recover hook")
+		}
+	}
+
+	return nil
+}
+
+// checkOnce checks methods can be called by once hook, calls replayed for
+// items appended later have nobody to return results to
+func (this *Hookgen) checkOnce(methods []Method) error {
+	for _, m := range methods {
+		if len(m.Results) != 0 {
+			return this.unsupported(m.Name, "can't return results in once hook")
+		}
+	}
+
+	return nil
+}
+
+// checkFuncs checks methods don't conflict with fields of the struct of
+// functions, the method "OnStart" conflicts with the field for "Start"
+func (this *Hookgen) checkFuncs(methods []Method) error {
+	if len(methods) < 2 {
+		return nil
+	}
+
+	names := map[string]bool{}
+	for _, m := range methods {
+		names[m.Name] = true
+	}
+
+	for _, m := range methods {
+		if names["On"+m.Name] {
+			return this.unsupported("On"+m.Name, "conflicts with the field of funcs adapter")
+		}
+	}
+
+	return nil
+}
+
+// checkRecorder checks methods don't conflict with methods and fields of
+// the recorder, and parameters of every method give distinct fields of the
+// recorded call, "a" and "A" both give the field "A"
+func (this *Hookgen) checkRecorder(methods []Method) error {
+	for _, m := range methods {
+		switch m.Name {
+		case "Calls", "Reset", "WaitForCalls", "record", "m", "calls", "changed":
+			return this.unsupported(m.Name, "conflicts with the method of recorder")
+		}
+
+		fields := map[string]string{}
+		for _, p := range m.Params {
+			if prev, ok := fields[p.Field()]; ok {
+				return this.unsupported(m.Name, fmt.Sprintf("has parameters '%s' and '%s' giving the same field '%s' of recorded call", prev, p.Name, p.Field()))
+			}
+
+			fields[p.Field()] = p.Name
+		}
+	}
+
+	return nil
+}
+
+// checkAsync checks methods can be called by async hook
+func (this *Hookgen) checkAsync(methods []Method) error {
+	for _, m := range methods {
+		if len(m.Results) != 0 {
+			return this.unsupported(m.Name, "can't return results in async hook")
+		}
+
+		if m.Name == "Start" || m.Name == "Close" {
+			return this.unsupported(m.Name, "conflicts with the method of async hook")
+		}
+	}
+
+	return nil
+}
+
+// unsupported returns the error for the method which can't be hooked
+func (this *Hookgen) unsupported(method, reason string) error {
+	return this.locate(&UnsupportedSignatureError{Method: method, Reason: reason})
+}
+
+// locate sets the position of the method to UnsupportedSignatureError if
+// it's known
+func (this *Hookgen) locate(err error) error {
+	var serr *UnsupportedSignatureError
+	if errors.As(err, &serr) && !serr.Pos.IsValid() {
+		serr.Pos = this.methodPos[serr.Method]
+	}
+
+	return err
+}
+
 type formatterFunc func([]byte) ([]byte, error)
 
 func (this *Hookgen) formatter() formatterFunc {
@@ -65,12 +580,16 @@ func (this *Hookgen) formatter() formatterFunc {
 	}
 }
 
-func (this *Hookgen) interfaceName(name string) string {
+func (this *Hookgen) interfaceName(iface *types.Interface, name string) string {
 	if resource.Package(this.Src) == resource.Package(this.Dst) {
 		return name
 	}
 
-	return path.Base(resource.Package(this.Src)) + "." + name
+	if q := this.importer().add(this.srcPackage(iface)); q != "" {
+		return q + "." + name
+	}
+
+	return name
 }
 
 func (this *Hookgen) hookName(name string) string {
@@ -82,6 +601,10 @@ func (this *Hookgen) hookName(name string) string {
 }
 
 func (this *Hookgen) packageName(name string) string {
+	if this.Package != "" {
+		return this.Package
+	}
+
 	srcPkgName := path.Base(resource.Package(this.Src))
 	dstPkgName := path.Base(resource.Package(this.Dst))
 
@@ -89,72 +612,274 @@ func (this *Hookgen) packageName(name string) string {
 		return name
 	}
 
-	return dstPkgName
+	return newPackageName(resource.Package(this.Dst))
+}
+
+// newPackageName returns the name of new package of import path or
+// directory p like "ev" for "example.com/ev/v2" and "goev" for "./go-ev"
+func newPackageName(p string) string {
+	p = path.Clean(filepath.ToSlash(p))
+
+	name := path.Base(p)
+	if isMajorVersion(name) && path.Dir(p) != "." {
+		name = path.Base(path.Dir(p))
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+
+		return -1
+	}, name)
+
+	if r, _ := utf8.DecodeRuneInString(name); name == "" || unicode.IsDigit(r) {
+		name = "p" + name
+	}
+
+	return name
+}
+
+// isMajorVersion reports whether the element of import path is the major
+// version suffix like "v2"
+func isMajorVersion(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' || elem[1] == '0' {
+		return false
+	}
+
+	for _, r := range elem[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
 }
 
-func (this *Hookgen) methodName(iface *types.Interface) string {
-	return iface.Method(0).Name()
+// typeParams returns type parameters of generic interface for declarations
+// like "[K comparable, V any]" and for uses like "[K, V]", they are empty
+// for other interfaces
+func (this *Hookgen) typeParams(named *types.Named) (string, string) {
+	if named == nil || named.TypeParams().Len() == 0 || named.TypeArgs().Len() != 0 {
+		return "", ""
+	}
+
+	decls := []string{}
+	names := []string{}
+
+	for i := 0; i < named.TypeParams().Len(); i++ {
+		tp := named.TypeParams().At(i)
+
+		decls = append(decls, tp.Obj().Name()+" "+types.TypeString(tp.Constraint(), this.qualifier))
+		names = append(names, tp.Obj().Name())
+	}
+
+	return "[" + strings.Join(decls, ", ") + "]", "[" + strings.Join(names, ", ") + "]"
 }
 
-func (this *Hookgen) methodDeclArgs(iface *types.Interface) []string {
+// interfaceArgs returns type arguments of the interface in the hook, they
+// are type arguments of instantiated interface or type parameters of
+// generic one
+func (this *Hookgen) interfaceArgs(named *types.Named) string {
+	if named == nil || named.TypeArgs().Len() == 0 {
+		_, typeArgs := this.typeParams(named)
+		return typeArgs
+	}
+
 	args := []string{}
+	for i := 0; i < named.TypeArgs().Len(); i++ {
+		args = append(args, types.TypeString(named.TypeArgs().At(i), this.qualifier))
+	}
 
-	sign := iface.Method(0).Type().(*types.Signature)
-	prms := sign.Params()
+	return "[" + strings.Join(args, ", ") + "]"
+}
+
+// typeImports returns types which are used by the hook besides methods:
+// type arguments of instantiated interface or constraints of generic one
+func (this *Hookgen) typeImports(named *types.Named) []types.Type {
+	ts := []types.Type{}
+
+	if named == nil {
+		return ts
+	}
+
+	for i := 0; i < named.TypeArgs().Len(); i++ {
+		ts = append(ts, named.TypeArgs().At(i))
+	}
+
+	if named.TypeArgs().Len() == 0 {
+		for i := 0; i < named.TypeParams().Len(); i++ {
+			ts = append(ts, named.TypeParams().At(i).Constraint())
+		}
+	}
+
+	return ts
+}
+
+// srcPackage returns path and name of the package declaring the interface.
+// Methods of embedded interfaces belong to other packages, so the package
+// is taken from methods declared in the interface itself, if there are no
+// such methods then from the package of Src.
+func (this *Hookgen) srcPackage(iface *types.Interface) (string, string) {
+	if iface.NumExplicitMethods() != 0 {
+		p := iface.ExplicitMethod(0).Pkg()
+		return p.Path(), p.Name()
+	}
 
-	qualifier := func(p *types.Package) string {
-		if this.Src != "" && this.Src == p.Path() {
-			return ""
+	srcPkg := resource.Package(this.Src)
+
+	for i := 0; i < iface.NumMethods(); i++ {
+		if p := iface.Method(i).Pkg(); p != nil && p.Path() == srcPkg {
+			return p.Path(), p.Name()
 		}
+	}
 
-		return p.Name()
+	return srcPkg, path.Base(srcPkg)
+}
+
+// methods returns all methods of the interface including methods of
+// embedded interfaces, they are sorted by name
+func (this *Hookgen) methods(iface *types.Interface) ([]Method, error) {
+	ss, err := parseStrategies(this.Results)
+	if err != nil {
+		return nil, err
 	}
 
-	for i := 0; i < prms.Len(); i++ {
-		prm := prms.At(i)
+	if err := ss.check(iface); err != nil {
+		return nil, err
+	}
+
+	methods := make([]Method, 0, iface.NumMethods())
+
+	for i := 0; i < iface.NumMethods(); i++ {
+		meth := iface.Method(i)
+
+		strategy, err := ss.strategy(meth)
+		if err != nil {
+			return nil, this.locate(err)
+		}
+
+		results, err := this.methodResults(meth, strategy)
+		if err != nil {
+			return nil, err
+		}
+
+		methods = append(methods, Method{
+			Name:        this.methodName(meth),
+			DeclArgs:    this.methodDeclArgs(meth),
+			CallArgs:    this.methodCallArgs(meth),
+			Params:      this.methodParams(meth),
+			DeclResults: this.methodDeclResults(meth, strategy),
+			Results:     results,
+			Strategy:    strategy,
+			Context:     this.methodContext(meth),
+			Doc:         this.Docs.Methods[meth.Name()],
+		})
+	}
+
+	return methods, nil
+}
 
-		isLast := i == prms.Len()-1
-		isSlice := prm.Type().String()[0:2] == "[]"
-		isVariadic := sign.Variadic()
+// importer returns imports of the hook being generated, types of the
+// package of Dst are local
+func (this *Hookgen) importer() *imports {
+	if this.imports == nil {
+		this.imports = newImports(resource.Package(this.Dst))
+	}
+
+	return this.imports
+}
 
-		n := prm.Name()
+func (this *Hookgen) qualifier(p *types.Package) string {
+	return this.importer().qualifier(p)
+}
+
+// paramNames returns names of parameters of the method, unnamed parameters
+// are named like "arg0" and parameters clashing with imports, variables of
+// the hook or other parameters get a number like "list1"
+func (this *Hookgen) paramNames(meth *types.Func) []string {
+	prms := meth.Type().(*types.Signature).Params()
+
+	names := make([]string, 0, prms.Len())
+	seen := map[string]bool{}
+
+	for i := 0; i < prms.Len(); i++ {
+		n := prms.At(i).Name()
 		if n == "" || n == "_" {
 			n = fmt.Sprintf("arg%d", i)
 		}
 
-		t := types.TypeString(prm.Type(), qualifier)
-		if isLast && isSlice && isVariadic {
-			t = "..." + t[2:]
+		for base, j := n, 1; seen[n] || this.isReserved(meth, n); j++ {
+			n = fmt.Sprintf("%s%d", base, j)
 		}
 
-		arg := n + " " + t
+		seen[n] = true
+		names = append(names, n)
+	}
 
-		args = append(args, arg)
+	return names
+}
+
+func (this *Hookgen) isReserved(meth *types.Func, name string) bool {
+	if reservedNames[name] || this.importer().isTaken(name) {
+		return true
 	}
 
-	return args
+	// names of results and variables for them
+	for i := 0; i < meth.Type().(*types.Signature).Results().Len(); i++ {
+		if name == fmt.Sprintf("r%d", i) || name == fmt.Sprintf("v%d", i) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (this *Hookgen) methodName(meth *types.Func) string {
+	return meth.Name()
 }
 
-func (this *Hookgen) methodCallArgs(iface *types.Interface) []string {
+func (this *Hookgen) methodDeclArgs(meth *types.Func) []string {
 	args := []string{}
 
-	sign := iface.Method(0).Type().(*types.Signature)
+	sign := meth.Type().(*types.Signature)
 	prms := sign.Params()
+	names := this.paramNames(meth)
 
 	for i := 0; i < prms.Len(); i++ {
 		prm := prms.At(i)
 
-		isLast := i == prms.Len()-1
-		isSlice := prm.Type().String()[0:2] == "[]"
-		isVariadic := sign.Variadic()
+		isVariadic := sign.Variadic() && i == prms.Len()-1
 
-		n := prm.Name()
-		if n == "" || n == "_" {
-			n = fmt.Sprintf("arg%d", i)
+		n := names[i]
+
+		t := types.TypeString(prm.Type(), this.qualifier)
+		if isVariadic {
+			t = "..." + types.TypeString(prm.Type().(*types.Slice).Elem(), this.qualifier)
 		}
 
+		arg := n + " " + t
+
+		args = append(args, arg)
+	}
+
+	return args
+}
+
+func (this *Hookgen) methodCallArgs(meth *types.Func) []string {
+	args := []string{}
+
+	sign := meth.Type().(*types.Signature)
+	prms := sign.Params()
+	names := this.paramNames(meth)
+
+	for i := 0; i < prms.Len(); i++ {
+		isVariadic := sign.Variadic() && i == prms.Len()-1
+
+		n := names[i]
+
 		arg := n
-		if isLast && isSlice && isVariadic {
+		if isVariadic {
 			arg = arg + "..."
 		}
 
@@ -164,29 +889,116 @@ func (this *Hookgen) methodCallArgs(iface *types.Interface) []string {
 	return args
 }
 
-func (this *Hookgen) methodImports(iface *types.Interface) []string {
-	importsMap := map[string]struct{}{}
-	meth := iface.Method(0)
+func (this *Hookgen) methodParams(meth *types.Func) []Param {
+	params := []Param{}
+
 	sign := meth.Type().(*types.Signature)
 	prms := sign.Params()
+	names := this.paramNames(meth)
 
-	if resource.Package(this.Src) != resource.Package(this.Dst) {
-		importsMap[meth.Pkg().Path()] = struct{}{}
+	for i := 0; i < prms.Len(); i++ {
+		prm := prms.At(i)
+
+		n := names[i]
+
+		params = append(params, Param{
+			Name:     n,
+			Type:     types.TypeString(prm.Type(), this.qualifier),
+			Variadic: sign.Variadic() && i == prms.Len()-1,
+		})
 	}
 
-	qualifier := func(p *types.Package) string {
-		importsMap[p.Path()] = struct{}{}
+	return params
+}
+
+// methodContext returns name of the first argument if it is context.Context
+func (this *Hookgen) methodContext(meth *types.Func) string {
+	prms := meth.Type().(*types.Signature).Params()
+	if prms.Len() == 0 || !isContext(prms.At(0).Type()) {
 		return ""
 	}
 
-	for i := 0; i < prms.Len(); i++ {
-		types.TypeString(prms.At(i).Type(), qualifier)
+	return this.paramNames(meth)[0]
+}
+
+func isContext(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+
+	obj := named.Obj()
+
+	return obj.Pkg() != nil && obj.Pkg().Path() == "context" && obj.Name() == "Context"
+}
+
+func (this *Hookgen) methodDeclResults(meth *types.Func, strategy string) []string {
+	results := []string{}
+
+	res := meth.Type().(*types.Signature).Results()
+
+	for i := 0; i < res.Len(); i++ {
+		t := types.TypeString(res.At(i).Type(), this.qualifier)
+		if strategy == ResultsAll {
+			t = "[]" + t
+		}
+
+		results = append(results, fmt.Sprintf("r%d %s", i, t))
+	}
+
+	return results
+}
+
+func (this *Hookgen) methodResults(meth *types.Func, strategy string) ([]Result, error) {
+	results := []Result{}
+
+	res := meth.Type().(*types.Signature).Results()
+
+	for i := 0; i < res.Len(); i++ {
+		r := Result{
+			Name:    fmt.Sprintf("r%d", i),
+			Var:     fmt.Sprintf("v%d", i),
+			Type:    types.TypeString(res.At(i).Type(), this.qualifier),
+			IsError: isError(res.At(i).Type()),
+		}
+
+		if strategy == ResultsFirst {
+			nz, err := nonZero(r.Var, res.At(i).Type(), this.qualifier)
+			if err != nil {
+				return nil, this.unsupported(meth.Name(), fmt.Sprintf("can't return results with result strategy '%s': %s", strategy, err))
+			}
+
+			r.NonZero = nz
+		}
+
+		results = append(results, r)
+	}
+
+	return results, nil
+}
+
+// methodImports returns imports of types used by methods of the interface
+// and extra types
+func (this *Hookgen) methodImports(iface *types.Interface, extra ...types.Type) []Import {
+	if resource.Package(this.Src) != resource.Package(this.Dst) {
+		this.importer().add(this.srcPackage(iface))
+	}
+
+	for i := 0; i < iface.NumMethods(); i++ {
+		sign := iface.Method(i).Type().(*types.Signature)
+
+		for j := 0; j < sign.Params().Len(); j++ {
+			types.TypeString(sign.Params().At(j).Type(), this.qualifier)
+		}
+
+		for j := 0; j < sign.Results().Len(); j++ {
+			types.TypeString(sign.Results().At(j).Type(), this.qualifier)
+		}
 	}
 
-	imports := make([]string, 0, len(importsMap))
-	for i := range importsMap {
-		imports = append(imports, i)
+	for _, t := range extra {
+		types.TypeString(t, this.qualifier)
 	}
 
-	return imports
+	return this.importer().list()
 }
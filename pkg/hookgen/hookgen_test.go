@@ -2,73 +2,414 @@ package hookgen
 
 import (
 	"bytes"
-	"fmt"
 	"go/types"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
-
-	"golang.org/x/tools/go/packages"
 )
 
 func mustLoadInterface(dir, name string) *types.Interface {
-	ps, err := packages.Load(&packages.Config{
-		Mode: packages.NeedTypes,
-		Dir:  dir,
-	})
+	return mustLoadType(dir, name).Underlying().(*types.Interface)
+}
+
+func mustLoadType(dir, name string) types.Type {
+	iface, err := Load(dir, name)
+	if err != nil {
+		panic(err)
+	}
+
+	return iface.Type
+}
+
+func mustInstantiate(t types.Type, args ...types.Type) types.Type {
+	instance, err := types.Instantiate(nil, t, args, true)
 	if err != nil {
 		panic(err)
 	}
 
-	if len(ps) == 0 {
-		panic(fmt.Sprintf("There are no packages in '%s'", dir))
+	return instance
+}
+
+func TestHookgen_Generate(t *testing.T) {
+	type fields struct {
+		SrcPkg    string
+		DstPkg    string
+		Safe      bool
+		Formatter string
+	}
+	type args struct {
+		i *types.Interface
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantW   string
+		wantErr bool
+	}{
+		{
+			name: "Generate() fails on interface with type constraints",
+			fields: fields{
+				SrcPkg: "./internal/instance.Interface9",
+				DstPkg: "./internal/instance.Hook",
+			},
+			args: args{
+				i: mustLoadInterface("./internal/instance", "Interface9"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			this := &Hookgen{
+				Src:       tt.fields.SrcPkg,
+				Dst:       tt.fields.DstPkg,
+				Safe:      tt.fields.Safe,
+				Formatter: tt.fields.Formatter,
+			}
+			w := &bytes.Buffer{}
+			if err := this.Generate(w, tt.args.i); (err != nil) != tt.wantErr {
+				t.Errorf("Hookgen.Generate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotW := w.String(); gotW != tt.wantW {
+				t.Errorf("Hookgen.Generate() = %v, want %v", gotW, tt.wantW)
+			}
+		})
+	}
+}
+
+// TestHookgen_GenerateType_concurrent is useful with -race
+func TestHookgen_GenerateType_concurrent(t *testing.T) {
+	this := &Hookgen{
+		Src: "github.com/axard/things/pkg/hookgen/internal/instance.Interface11",
+		Dst: "github.com/axard/things/pkg/hookgen/internal/instance.Hook",
 	}
+	iface := mustLoadType("./internal/instance", "Interface11")
 
-	if len(ps) > 1 {
-		panic(fmt.Sprintf("Too many packages in '%s'", dir))
+	want := &bytes.Buffer{}
+	if err := this.GenerateType(want, iface); err != nil {
+		t.Fatal(err)
 	}
 
-	if errs := ps[0].Errors; len(errs) != 0 {
-		errstr := make([]string, 0, len(errs)+1)
-		errstr = append(errstr, "there are many errors:")
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			w := &bytes.Buffer{}
+			if err := this.GenerateType(w, iface); err != nil {
+				t.Error(err)
+				return
+			}
+
+			if w.String() != want.String() {
+				t.Errorf("Hookgen.GenerateType() = %v, want %v", w, want)
+			}
+		}()
+	}
 
-		for _, err := range errs {
-			errstr = append(errstr, err.Error())
-		}
+	wg.Wait()
+}
 
-		panic(strings.Join(errstr, "\n - "))
+func TestHookgen_GenerateType_async(t *testing.T) {
+	this := &Hookgen{
+		Src:      "github.com/axard/things/pkg/hookgen/internal/instance.Interface3",
+		Dst:      "github.com/axard/things/pkg/hookgen/internal/instance.Hook",
+		Dispatch: DispatchAsync,
 	}
 
-	obj := ps[0].Types.Scope().Lookup(name)
-	if obj == nil {
-		panic(fmt.Sprintf("There is no interface with name '%s' in dir '%s'", name, dir))
+	w := &bytes.Buffer{}
+	if err := this.GenerateType(w, mustLoadType("./internal/instance", "Interface3")); err != nil {
+		t.Fatal(err)
 	}
 
-	if !types.IsInterface(obj.Type()) {
-		panic(fmt.Sprintf("'%s' is not an interface; it is '%s'", name, obj.Type().String()))
+	// the worker reads the list while the caller changes it
+	if want := "list := this.items()"; !strings.Contains(w.String(), want) {
+		t.Errorf("Hookgen.GenerateType() = %v, want %v in it", w, want)
 	}
+}
 
-	return obj.Type().Underlying().(*types.Interface)
+func TestHookgen_GenerateTests(t *testing.T) {
+	type args struct {
+		t types.Type
+	}
+	tests := []struct {
+		name     string
+		src      string
+		results  string
+		dispatch string
+		args     args
+		wantTest string
+		wantErr  bool
+	}{
+		{
+			name: "GenerateTests() fails on generic interface",
+			src:  "Interface10",
+			args: args{
+				t: mustLoadType("./internal/instance", "Interface10"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "GenerateTests() generates tests of hook for instantiated interface",
+			src:  "Interface10",
+			args: args{
+				t: mustInstantiate(
+					mustLoadType("./internal/instance", "Interface10"),
+					types.Typ[types.String],
+					mustLoadType("./internal/instance", "Interface8"),
+				),
+			},
+			wantTest: "func TestHook_order(t *testing.T) {",
+			wantErr:  false,
+		},
+		{
+			name:    "GenerateTests() implements interface by items when hook aggregates results",
+			src:     "Interface5",
+			results: ResultsAll,
+			args: args{
+				t: mustLoadType("./internal/instance", "Interface5"),
+			},
+			wantTest: "func (this testHookItem) Check() (r0 bool) {",
+			wantErr:  false,
+		},
+		{
+			name:     "GenerateTests() tests concurrent use of async hook without Safe",
+			src:      "Interface3",
+			dispatch: DispatchAsync,
+			args: args{
+				t: mustLoadType("./internal/instance", "Interface3"),
+			},
+			wantTest: "func TestHook_concurrent(t *testing.T) {",
+			wantErr:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			this := &Hookgen{
+				Src:      "github.com/axard/things/pkg/hookgen/internal/instance." + tt.src,
+				Dst:      "github.com/axard/things/pkg/hookgen/internal/instance.Hook",
+				Results:  tt.results,
+				Dispatch: tt.dispatch,
+			}
+			w := &bytes.Buffer{}
+			if err := this.GenerateTests(w, tt.args.t); (err != nil) != tt.wantErr {
+				t.Errorf("Hookgen.GenerateTests() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotW := w.String(); !strings.Contains(gotW, tt.wantTest) {
+				t.Errorf("Hookgen.GenerateTests() = %v, want %v in it", gotW, tt.wantTest)
+			}
+		})
+	}
 }
 
-func TestHookgen_Generate(t *testing.T) {
+func TestHookgen_methods(t *testing.T) {
 	type fields struct {
 		SrcPkg    string
 		DstPkg    string
 		Safe      bool
+		Results   string
 		Formatter string
 	}
 	type args struct {
-		i *types.Interface
+		iface *types.Interface
 	}
 	tests := []struct {
 		name    string
 		fields  fields
 		args    args
-		wantW   string
+		want    []Method
 		wantErr bool
 	}{
-		// TODO: Add test cases.
+		{
+			name:   "",
+			fields: fields{},
+			args: args{
+				iface: mustLoadInterface("./internal/instance", "Interface0"),
+			},
+			want: []Method{
+				{
+					Name:        "Method",
+					DeclArgs:    []string{"arg0 interface{}"},
+					CallArgs:    []string{"arg0"},
+					Params:      []Param{{Name: "arg0", Type: "interface{}"}},
+					DeclResults: []string{},
+					Results:     []Result{},
+				},
+			},
+		},
+		{
+			name:   "",
+			fields: fields{},
+			args: args{
+				iface: mustLoadInterface("./internal/instance", "Interface4"),
+			},
+			want: []Method{
+				{
+					Name:        "Start",
+					DeclArgs:    []string{"s string"},
+					CallArgs:    []string{"s"},
+					Params:      []Param{{Name: "s", Type: "string"}},
+					DeclResults: []string{},
+					Results:     []Result{},
+				},
+				{
+					Name:        "Stop",
+					DeclArgs:    []string{},
+					CallArgs:    []string{},
+					Params:      []Param{},
+					DeclResults: []string{},
+					Results:     []Result{},
+				},
+			},
+		},
+		{
+			name:   "",
+			fields: fields{},
+			args: args{
+				iface: mustLoadInterface("./internal/instance", "Interface5"),
+			},
+			want: []Method{
+				{
+					Name:        "Check",
+					DeclArgs:    []string{},
+					CallArgs:    []string{},
+					Params:      []Param{},
+					DeclResults: []string{"r0 bool"},
+					Results:     []Result{{Name: "r0", Var: "v0", Type: "bool"}},
+					Strategy:    ResultsLast,
+				},
+				{
+					Name:        "Close",
+					DeclArgs:    []string{},
+					CallArgs:    []string{},
+					Params:      []Param{},
+					DeclResults: []string{"r0 error"},
+					Results:     []Result{{Name: "r0", Var: "v0", Type: "error", IsError: true}},
+					Strategy:    ResultsFirstError,
+				},
+			},
+		},
+		{
+			name: "",
+			fields: fields{
+				Results: "first,Close=all",
+			},
+			args: args{
+				iface: mustLoadInterface("./internal/instance", "Interface5"),
+			},
+			want: []Method{
+				{
+					Name:        "Check",
+					DeclArgs:    []string{},
+					CallArgs:    []string{},
+					Params:      []Param{},
+					DeclResults: []string{"r0 bool"},
+					Results:     []Result{{Name: "r0", Var: "v0", Type: "bool", NonZero: "v0"}},
+					Strategy:    ResultsFirst,
+				},
+				{
+					Name:        "Close",
+					DeclArgs:    []string{},
+					CallArgs:    []string{},
+					Params:      []Param{},
+					DeclResults: []string{"r0 []error"},
+					Results:     []Result{{Name: "r0", Var: "v0", Type: "error", IsError: true}},
+					Strategy:    ResultsAll,
+				},
+			},
+		},
+		{
+			name:   "",
+			fields: fields{},
+			args: args{
+				iface: mustLoadInterface("./internal/instance", "Interface6"),
+			},
+			want: []Method{
+				{
+					Name:        "Notify",
+					DeclArgs:    []string{"arg0 context.Context"},
+					CallArgs:    []string{"arg0"},
+					Params:      []Param{{Name: "arg0", Type: "context.Context"}},
+					DeclResults: []string{},
+					Results:     []Result{},
+					Context:     "arg0",
+				},
+				{
+					Name:        "Shutdown",
+					DeclArgs:    []string{"ctx context.Context"},
+					CallArgs:    []string{"ctx"},
+					Params:      []Param{{Name: "ctx", Type: "context.Context"}},
+					DeclResults: []string{"r0 error"},
+					Results:     []Result{{Name: "r0", Var: "v0", Type: "error", IsError: true}},
+					Strategy:    ResultsFirstError,
+					Context:     "ctx",
+				},
+			},
+		},
+		{
+			name:   "methods() returns methods of embedded interfaces sorted by name",
+			fields: fields{},
+			args: args{
+				iface: mustLoadInterface("./internal/instance", "Interface7"),
+			},
+			want: []Method{
+				{
+					Name:        "Close",
+					DeclArgs:    []string{},
+					CallArgs:    []string{},
+					Params:      []Param{},
+					DeclResults: []string{"r0 error"},
+					Results:     []Result{{Name: "r0", Var: "v0", Type: "error", IsError: true}},
+					Strategy:    ResultsFirstError,
+				},
+				{
+					Name:        "Flush",
+					DeclArgs:    []string{},
+					CallArgs:    []string{},
+					Params:      []Param{},
+					DeclResults: []string{"r0 error"},
+					Results:     []Result{{Name: "r0", Var: "v0", Type: "error", IsError: true}},
+					Strategy:    ResultsFirstError,
+				},
+			},
+		},
+		{
+			name: "",
+			fields: fields{
+				Results: "all-errors",
+			},
+			args: args{
+				iface: mustLoadInterface("./internal/instance", "Interface5"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "",
+			fields: fields{
+				Results: "unknown",
+			},
+			args: args{
+				iface: mustLoadInterface("./internal/instance", "Interface4"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "methods() rejects strategy of unknown method",
+			fields: fields{
+				Results: "Clse=all-errors",
+			},
+			args: args{
+				iface: mustLoadInterface("./internal/instance", "Interface5"),
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -76,15 +417,16 @@ func TestHookgen_Generate(t *testing.T) {
 				Src:       tt.fields.SrcPkg,
 				Dst:       tt.fields.DstPkg,
 				Safe:      tt.fields.Safe,
+				Results:   tt.fields.Results,
 				Formatter: tt.fields.Formatter,
 			}
-			w := &bytes.Buffer{}
-			if err := this.Generate(w, tt.args.i); (err != nil) != tt.wantErr {
-				t.Errorf("Hookgen.Generate() error = %v, wantErr %v", err, tt.wantErr)
+			got, err := this.methods(tt.args.iface)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Hookgen.methods() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if gotW := w.String(); gotW != tt.wantW {
-				t.Errorf("Hookgen.Generate() = %v, want %v", gotW, tt.wantW)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Hookgen.methods() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -98,7 +440,7 @@ func TestHookgen_methodName(t *testing.T) {
 		Formatter string
 	}
 	type args struct {
-		iface *types.Interface
+		meth *types.Func
 	}
 	tests := []struct {
 		name   string
@@ -110,7 +452,7 @@ func TestHookgen_methodName(t *testing.T) {
 			name:   "",
 			fields: fields{},
 			args: args{
-				iface: mustLoadInterface("./internal/instance", "Interface0"),
+				meth: mustLoadInterface("./internal/instance", "Interface0").Method(0),
 			},
 			want: "Method",
 		},
@@ -118,7 +460,7 @@ func TestHookgen_methodName(t *testing.T) {
 			name:   "",
 			fields: fields{},
 			args: args{
-				iface: mustLoadInterface("./internal/instance", "Interface1"),
+				meth: mustLoadInterface("./internal/instance", "Interface1").Method(0),
 			},
 			want: "Method",
 		},
@@ -126,10 +468,18 @@ func TestHookgen_methodName(t *testing.T) {
 			name:   "",
 			fields: fields{},
 			args: args{
-				iface: mustLoadInterface("./internal/instance", "Interface2"),
+				meth: mustLoadInterface("./internal/instance", "Interface2").Method(0),
 			},
 			want: "Method",
 		},
+		{
+			name:   "",
+			fields: fields{},
+			args: args{
+				meth: mustLoadInterface("./internal/instance", "Interface4").Method(1),
+			},
+			want: "Stop",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -139,7 +489,7 @@ func TestHookgen_methodName(t *testing.T) {
 				Safe:      tt.fields.Safe,
 				Formatter: tt.fields.Formatter,
 			}
-			if got := this.methodName(tt.args.iface); got != tt.want {
+			if got := this.methodName(tt.args.meth); got != tt.want {
 				t.Errorf("Hookgen.methodName() = %v, want %v", got, tt.want)
 			}
 		})
@@ -154,7 +504,7 @@ func TestHookgen_methodDeclArgs(t *testing.T) {
 		Formatter string
 	}
 	type args struct {
-		iface *types.Interface
+		meth *types.Func
 	}
 	tests := []struct {
 		name   string
@@ -166,7 +516,7 @@ func TestHookgen_methodDeclArgs(t *testing.T) {
 			name:   "",
 			fields: fields{},
 			args: args{
-				iface: mustLoadInterface("./internal/instance", "Interface0"),
+				meth: mustLoadInterface("./internal/instance", "Interface0").Method(0),
 			},
 			want: []string{"arg0 interface{}"},
 		},
@@ -174,7 +524,7 @@ func TestHookgen_methodDeclArgs(t *testing.T) {
 			name:   "",
 			fields: fields{},
 			args: args{
-				iface: mustLoadInterface("./internal/instance", "Interface1"),
+				meth: mustLoadInterface("./internal/instance", "Interface1").Method(0),
 			},
 			want: []string{"arg0 ...interface{}"},
 		},
@@ -182,27 +532,36 @@ func TestHookgen_methodDeclArgs(t *testing.T) {
 			name:   "",
 			fields: fields{},
 			args: args{
-				iface: mustLoadInterface("./internal/instance", "Interface2"),
+				meth: mustLoadInterface("./internal/instance", "Interface2").Method(0),
 			},
 			want: []string{"s string", "arg1 interface{}"},
 		},
+		{
+			name:   "methodDeclArgs() keeps type parameters of generic interface",
+			fields: fields{},
+			args: args{
+				meth: mustLoadInterface("./internal/instance", "Interface10").Method(0),
+			},
+			want: []string{"key K", "values ...V"},
+		},
 		{
 			name: "",
 			fields: fields{
-				SrcPkg: "github.com/axard/things/pkg/hookgen/internal/instance",
+				SrcPkg: "github.com/axard/things/pkg/hookgen/internal/instance.Interface3",
+				DstPkg: "github.com/axard/things/pkg/hookgen/internal/instance.Hook",
 			},
 			args: args{
-				iface: mustLoadInterface("./internal/instance", "Interface3"),
+				meth: mustLoadInterface("./internal/instance", "Interface3").Method(0),
 			},
-			want: []string{"i int", "s Struct"},
+			want: []string{"i1 int", "s Struct"},
 		},
 		{
 			name:   "",
 			fields: fields{},
 			args: args{
-				iface: mustLoadInterface("./internal/instance", "Interface3"),
+				meth: mustLoadInterface("./internal/instance", "Interface3").Method(0),
 			},
-			want: []string{"i int", "s instance.Struct"},
+			want: []string{"i1 int", "s instance.Struct"},
 		},
 	}
 	for _, tt := range tests {
@@ -213,7 +572,7 @@ func TestHookgen_methodDeclArgs(t *testing.T) {
 				Safe:      tt.fields.Safe,
 				Formatter: tt.fields.Formatter,
 			}
-			if got := this.methodDeclArgs(tt.args.iface); !reflect.DeepEqual(got, tt.want) {
+			if got := this.methodDeclArgs(tt.args.meth); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("Hookgen.methodDeclArgs() = %v, want %v", got, tt.want)
 			}
 		})
@@ -228,7 +587,7 @@ func TestHookgen_methodCallArgs(t *testing.T) {
 		Formatter string
 	}
 	type args struct {
-		iface *types.Interface
+		meth *types.Func
 	}
 	tests := []struct {
 		name   string
@@ -240,7 +599,7 @@ func TestHookgen_methodCallArgs(t *testing.T) {
 			name:   "",
 			fields: fields{},
 			args: args{
-				iface: mustLoadInterface("./internal/instance", "Interface0"),
+				meth: mustLoadInterface("./internal/instance", "Interface0").Method(0),
 			},
 			want: []string{"arg0"},
 		},
@@ -248,7 +607,7 @@ func TestHookgen_methodCallArgs(t *testing.T) {
 			name:   "",
 			fields: fields{},
 			args: args{
-				iface: mustLoadInterface("./internal/instance", "Interface1"),
+				meth: mustLoadInterface("./internal/instance", "Interface1").Method(0),
 			},
 			want: []string{"arg0..."},
 		},
@@ -256,7 +615,7 @@ func TestHookgen_methodCallArgs(t *testing.T) {
 			name:   "",
 			fields: fields{},
 			args: args{
-				iface: mustLoadInterface("./internal/instance", "Interface2"),
+				meth: mustLoadInterface("./internal/instance", "Interface2").Method(0),
 			},
 			want: []string{"s", "arg1"},
 		},
@@ -264,9 +623,9 @@ func TestHookgen_methodCallArgs(t *testing.T) {
 			name:   "",
 			fields: fields{},
 			args: args{
-				iface: mustLoadInterface("./internal/instance", "Interface3"),
+				meth: mustLoadInterface("./internal/instance", "Interface3").Method(0),
 			},
-			want: []string{"i", "s"},
+			want: []string{"i1", "s"},
 		},
 	}
 	for _, tt := range tests {
@@ -277,7 +636,7 @@ func TestHookgen_methodCallArgs(t *testing.T) {
 				Safe:      tt.fields.Safe,
 				Formatter: tt.fields.Formatter,
 			}
-			if got := this.methodCallArgs(tt.args.iface); !reflect.DeepEqual(got, tt.want) {
+			if got := this.methodCallArgs(tt.args.meth); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("Hookgen.methodCallArgs() = %v, want %v", got, tt.want)
 			}
 		})
@@ -298,7 +657,7 @@ func TestHookgen_methodImports(t *testing.T) {
 		name   string
 		fields fields
 		args   args
-		want   []string
+		want   []Import
 	}{
 		{
 			name:   "",
@@ -306,7 +665,7 @@ func TestHookgen_methodImports(t *testing.T) {
 			args: args{
 				iface: mustLoadInterface("./internal/instance", "Interface0"),
 			},
-			want: []string{},
+			want: []Import{},
 		},
 		{
 			name:   "",
@@ -314,7 +673,7 @@ func TestHookgen_methodImports(t *testing.T) {
 			args: args{
 				iface: mustLoadInterface("./internal/instance", "Interface1"),
 			},
-			want: []string{},
+			want: []Import{},
 		},
 		{
 			name:   "",
@@ -322,7 +681,7 @@ func TestHookgen_methodImports(t *testing.T) {
 			args: args{
 				iface: mustLoadInterface("./internal/instance", "Interface2"),
 			},
-			want: []string{},
+			want: []Import{},
 		},
 		{
 			name:   "",
@@ -330,7 +689,18 @@ func TestHookgen_methodImports(t *testing.T) {
 			args: args{
 				iface: mustLoadInterface("./internal/instance", "Interface3"),
 			},
-			want: []string{"github.com/axard/things/pkg/hookgen/internal/instance"},
+			want: []Import{{Path: "github.com/axard/things/pkg/hookgen/internal/instance"}},
+		},
+		{
+			name: "methodImports() returns sorted imports of embedded interfaces and package of interface",
+			fields: fields{
+				SrcPkg: "github.com/axard/things/pkg/hookgen/internal/instance.Interface8",
+				DstPkg: "github.com/axard/things/pkg/hookgen.Hook",
+			},
+			args: args{
+				iface: mustLoadInterface("./internal/instance", "Interface8"),
+			},
+			want: []Import{{Path: "github.com/axard/things/pkg/hookgen/internal/instance"}, {Path: "io"}},
 		},
 	}
 	for _, tt := range tests {
@@ -347,3 +717,576 @@ func TestHookgen_methodImports(t *testing.T) {
 		})
 	}
 }
+
+func TestHookgen_paramNames(t *testing.T) {
+	type fields struct {
+		Imports []string
+	}
+	type args struct {
+		meth *types.Func
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   []string
+	}{
+		{
+			name:   "paramNames() names unnamed parameters",
+			fields: fields{},
+			args: args{
+				meth: mustLoadInterface("./internal/instance", "Interface2").Method(0),
+			},
+			want: []string{"s", "arg1"},
+		},
+		{
+			name: "paramNames() renames parameters clashing with variables, results and imports",
+			fields: fields{
+				Imports: []string{"log"},
+			},
+			args: args{
+				meth: mustLoadInterface("./internal/instance", "Interface11").Method(0),
+			},
+			want: []string{"list1", "log1", "r01", "sync1", "arg5", "arg51"},
+		},
+		{
+			name:   "paramNames() renames parameters clashing with variables of tests",
+			fields: fields{},
+			args: args{
+				meth: mustLoadInterface("./internal/instance", "Interface12").Method(0),
+			},
+			want: []string{"hook1", "s"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			this := &Hookgen{}
+			for _, name := range tt.fields.Imports {
+				this.importer().add(name, name)
+			}
+			if got := this.paramNames(tt.args.meth); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Hookgen.paramNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHookgen_typeParams(t *testing.T) {
+	type args struct {
+		named *types.Named
+	}
+	tests := []struct {
+		name          string
+		args          args
+		wantParams    string
+		wantArgs      string
+		wantInterface string
+	}{
+		{
+			name: "typeParams() returns nothing for not generic interface",
+			args: args{
+				named: mustLoadType("./internal/instance", "Interface0").(*types.Named),
+			},
+			wantParams:    "",
+			wantArgs:      "",
+			wantInterface: "",
+		},
+		{
+			name: "typeParams() returns type parameters of generic interface",
+			args: args{
+				named: mustLoadType("./internal/instance", "Interface10").(*types.Named),
+			},
+			wantParams:    "[K comparable, V fmt.Stringer]",
+			wantArgs:      "[K, V]",
+			wantInterface: "[K, V]",
+		},
+		{
+			name: "typeParams() returns nothing for instantiated interface",
+			args: args{
+				named: mustInstantiate(
+					mustLoadType("./internal/instance", "Interface10"),
+					types.Typ[types.String],
+					mustLoadType("./internal/instance", "Interface8"),
+				).(*types.Named),
+			},
+			wantParams:    "",
+			wantArgs:      "",
+			wantInterface: "[string, instance.Interface8]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			this := &Hookgen{}
+			gotParams, gotArgs := this.typeParams(tt.args.named)
+			if gotParams != tt.wantParams {
+				t.Errorf("Hookgen.typeParams() params = %v, want %v", gotParams, tt.wantParams)
+			}
+			if gotArgs != tt.wantArgs {
+				t.Errorf("Hookgen.typeParams() args = %v, want %v", gotArgs, tt.wantArgs)
+			}
+			if got := this.interfaceArgs(tt.args.named); got != tt.wantInterface {
+				t.Errorf("Hookgen.interfaceArgs() = %v, want %v", got, tt.wantInterface)
+			}
+		})
+	}
+}
+
+func TestHookgen_srcPackage(t *testing.T) {
+	type fields struct {
+		SrcPkg string
+	}
+	type args struct {
+		iface *types.Interface
+	}
+	tests := []struct {
+		name     string
+		fields   fields
+		args     args
+		wantPath string
+		wantName string
+	}{
+		{
+			name: "srcPackage() returns package of declared methods",
+			fields: fields{
+				SrcPkg: "./internal/instance.Interface7",
+			},
+			args: args{
+				iface: mustLoadInterface("./internal/instance", "Interface7"),
+			},
+			wantPath: "github.com/axard/things/pkg/hookgen/internal/instance",
+			wantName: "instance",
+		},
+		{
+			name: "srcPackage() returns package of Src if all methods are embedded",
+			fields: fields{
+				SrcPkg: "github.com/axard/things/pkg/hookgen/internal/instance.Interface8",
+			},
+			args: args{
+				iface: mustLoadInterface("./internal/instance", "Interface8"),
+			},
+			wantPath: "github.com/axard/things/pkg/hookgen/internal/instance",
+			wantName: "instance",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			this := &Hookgen{
+				Src: tt.fields.SrcPkg,
+			}
+			gotPath, gotName := this.srcPackage(tt.args.iface)
+			if gotPath != tt.wantPath {
+				t.Errorf("Hookgen.srcPackage() path = %v, want %v", gotPath, tt.wantPath)
+			}
+			if gotName != tt.wantName {
+				t.Errorf("Hookgen.srcPackage() name = %v, want %v", gotName, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestHookgen_packageName(t *testing.T) {
+	type fields struct {
+		Src     string
+		Dst     string
+		Package string
+	}
+	type args struct {
+		name string
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   string
+	}{
+		{
+			name:   "packageName() returns name of existing package",
+			fields: fields{Src: "example.com/mod/v2.Listener", Dst: "example.com/mod/v2.Hook", Package: "mod"},
+			args:   args{name: "mod"},
+			want:   "mod",
+		},
+		{
+			name:   "packageName() returns main for hook in package main",
+			fields: fields{Src: "./cmd.Listener", Dst: "./cmd.Hook"},
+			args:   args{name: "main"},
+			want:   "main",
+		},
+		{
+			name:   "packageName() skips major version of new package",
+			fields: fields{Src: "example.com/mod.Listener", Dst: "example.com/mod/hook/v2"},
+			args:   args{name: "mod"},
+			want:   "hook",
+		},
+		{
+			name:   "packageName() drops invalid characters of new package",
+			fields: fields{Src: "example.com/mod.Listener", Dst: "./go-ev"},
+			args:   args{name: "mod"},
+			want:   "goev",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			this := &Hookgen{
+				Src:     tt.fields.Src,
+				Dst:     tt.fields.Dst,
+				Package: tt.fields.Package,
+			}
+			if got := this.packageName(tt.args.name); got != tt.want {
+				t.Errorf("Hookgen.packageName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHookgen_dispatch(t *testing.T) {
+	type fields struct {
+		Dispatch    string
+		Concurrency int
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "sequential by default",
+			fields: fields{},
+			want:   DispatchSequential,
+		},
+		{
+			name:   "parallel",
+			fields: fields{Dispatch: DispatchParallel, Concurrency: 4},
+			want:   DispatchParallel,
+		},
+		{
+			name:    "unknown dispatch",
+			fields:  fields{Dispatch: "random"},
+			wantErr: true,
+		},
+		{
+			name:    "negative concurrency",
+			fields:  fields{Dispatch: DispatchParallel, Concurrency: -1},
+			wantErr: true,
+		},
+		{
+			name:    "concurrency of sequential dispatch",
+			fields:  fields{Concurrency: 4},
+			wantErr: true,
+		},
+		{
+			name:    "concurrency of async dispatch",
+			fields:  fields{Dispatch: DispatchAsync, Concurrency: 4},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			this := &Hookgen{
+				Dispatch:    tt.fields.Dispatch,
+				Concurrency: tt.fields.Concurrency,
+			}
+			got, err := this.dispatch()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Hookgen.dispatch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Hookgen.dispatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHookgen_overflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		overflow string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "block by default",
+			overflow: "",
+			want:     OverflowBlock,
+		},
+		{
+			name:     "drop oldest",
+			overflow: OverflowDropOldest,
+			want:     OverflowDropOldest,
+		},
+		{
+			name:     "unknown overflow",
+			overflow: "drop-random",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			this := &Hookgen{
+				Dispatch: DispatchAsync,
+				Overflow: tt.overflow,
+			}
+			got, err := this.overflow()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Hookgen.overflow() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Hookgen.overflow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHookgen_checkAsync(t *testing.T) {
+	tests := []struct {
+		name    string
+		methods []Method
+		wantErr bool
+	}{
+		{
+			name:    "methods without results",
+			methods: []Method{{Name: "Stop"}, {Name: "Notify"}},
+			wantErr: false,
+		},
+		{
+			name:    "method with results",
+			methods: []Method{{Name: "Stop", Results: []Result{{Name: "r0"}}}},
+			wantErr: true,
+		},
+		{
+			name:    "method conflicts with Close",
+			methods: []Method{{Name: "Close"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			this := &Hookgen{
+				Dispatch: DispatchAsync,
+			}
+			if err := this.checkAsync(tt.methods); (err != nil) != tt.wantErr {
+				t.Errorf("Hookgen.checkAsync() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHookgen_sync(t *testing.T) {
+	tests := []struct {
+		name    string
+		sync    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "mutex by default",
+			sync: "",
+			want: SyncMutex,
+		},
+		{
+			name: "atomic",
+			sync: SyncAtomic,
+			want: SyncAtomic,
+		},
+		{
+			name:    "unknown sync",
+			sync:    "spinlock",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			this := &Hookgen{
+				Safe: true,
+				Sync: tt.sync,
+			}
+			got, err := this.sync()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Hookgen.sync() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Hookgen.sync() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHookgen_order(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "fifo by default",
+			order: "",
+			want:  OrderFIFO,
+		},
+		{
+			name:  "lifo",
+			order: OrderLIFO,
+			want:  OrderLIFO,
+		},
+		{
+			name:    "unknown order",
+			order:   "random",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			this := &Hookgen{
+				Order: tt.order,
+			}
+			got, err := this.order()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Hookgen.order() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Hookgen.order() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHookgen_checkMethods(t *testing.T) {
+	tests := []struct {
+		name    string
+		recover bool
+		methods []Method
+		wantErr bool
+	}{
+		{
+			name:    "methods don't conflict",
+			methods: []Method{{Name: "Stop"}, {Name: "Notify"}},
+			wantErr: false,
+		},
+		{
+			name:    "method conflicts with Prepend",
+			methods: []Method{{Name: "Stop"}, {Name: "Prepend"}},
+			wantErr: true,
+		},
+		{
+			name:    "method OnPanic doesn't conflict without recover",
+			methods: []Method{{Name: "OnPanic"}},
+			wantErr: false,
+		},
+		{
+			name:    "method OnPanic conflicts with the field of recover hook",
+			recover: true,
+			methods: []Method{{Name: "OnPanic"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			this := &Hookgen{Recover: tt.recover}
+			if err := this.checkMethods(tt.methods); (err != nil) != tt.wantErr {
+				t.Errorf("Hookgen.checkMethods() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHookgen_checkFuncs(t *testing.T) {
+	tests := []struct {
+		name    string
+		methods []Method
+		wantErr bool
+	}{
+		{
+			name:    "one method doesn't need fields",
+			methods: []Method{{Name: "OnStart"}},
+			wantErr: false,
+		},
+		{
+			name:    "methods don't conflict",
+			methods: []Method{{Name: "Start"}, {Name: "Stop"}},
+			wantErr: false,
+		},
+		{
+			name:    "method conflicts with the field for another one",
+			methods: []Method{{Name: "Start"}, {Name: "OnStart"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			this := &Hookgen{Funcs: true}
+			if err := this.checkFuncs(tt.methods); (err != nil) != tt.wantErr {
+				t.Errorf("Hookgen.checkFuncs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHookgen_checkRecorder(t *testing.T) {
+	tests := []struct {
+		name    string
+		methods []Method
+		wantErr bool
+	}{
+		{
+			name:    "methods don't conflict",
+			methods: []Method{{Name: "Start"}, {Name: "Stop"}},
+			wantErr: false,
+		},
+		{
+			name:    "method conflicts with the method of recorder",
+			methods: []Method{{Name: "Start"}, {Name: "Reset"}},
+			wantErr: true,
+		},
+		{
+			name:    "method conflicts with the field of recorder",
+			methods: []Method{{Name: "calls"}},
+			wantErr: true,
+		},
+		{
+			name:    "parameters give the same field of recorded call",
+			methods: []Method{{Name: "On", Params: []Param{{Name: "a", Type: "int"}, {Name: "A", Type: "string"}}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			this := &Hookgen{Recorder: true}
+			if err := this.checkRecorder(tt.methods); (err != nil) != tt.wantErr {
+				t.Errorf("Hookgen.checkRecorder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHookgen_checkOnce(t *testing.T) {
+	tests := []struct {
+		name    string
+		methods []Method
+		wantErr bool
+	}{
+		{
+			name:    "methods without results",
+			methods: []Method{{Name: "Stop"}, {Name: "Notify"}},
+			wantErr: false,
+		},
+		{
+			name:    "method with results",
+			methods: []Method{{Name: "Stop", Results: []Result{{Name: "r0"}}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			this := &Hookgen{
+				Once: true,
+			}
+			if err := this.checkOnce(tt.methods); (err != nil) != tt.wantErr {
+				t.Errorf("Hookgen.checkOnce() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package hookgen
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+)
+
+// Import is a package imported by the hook, Name is set only if it differs
+// from the name of the package.
+type Import struct {
+	Name string
+	Path string
+}
+
+// imports assigns unique names to packages used by the hook, types of local
+// packages are used without qualifier
+type imports struct {
+	locals   map[string]bool
+	declared map[string]string // path -> name of package
+	names    map[string]string // path -> name in the hook
+	taken    map[string]string // name in the hook -> path
+	used     map[string]bool
+}
+
+func newImports(locals ...string) *imports {
+	this := &imports{
+		locals:   map[string]bool{},
+		declared: map[string]string{},
+		names:    map[string]string{},
+		taken:    map[string]string{},
+		used:     map[string]bool{},
+	}
+
+	for _, local := range locals {
+		this.locals[local] = true
+	}
+
+	// packages imported by the template itself keep their names
+	this.names["sync"], this.taken["sync"] = "sync", "sync"
+	this.names["sync/atomic"], this.taken["atomic"] = "atomic", "sync/atomic"
+
+	this.names["time"], this.taken["time"] = "time", "time"
+
+	// and the same for tests of the hook
+	for _, path := range []string{"reflect", "sort", "testing"} {
+		this.names[path], this.taken[path] = path, path
+	}
+
+	return this
+}
+
+// add returns the name of the package in the hook, it's empty for local
+// package. Packages with the same name get names like "log1", "log2".
+func (this *imports) add(path, name string) string {
+	if this.locals[path] {
+		return ""
+	}
+
+	this.used[path] = true
+	this.declared[path] = name
+
+	if n, ok := this.names[path]; ok {
+		return n
+	}
+
+	n := name
+	for i := 1; this.taken[n] != ""; i++ {
+		n = fmt.Sprintf("%s%d", name, i)
+	}
+
+	this.names[path], this.taken[n] = n, path
+
+	return n
+}
+
+func (this *imports) qualifier(p *types.Package) string {
+	return this.add(p.Path(), p.Name())
+}
+
+// isTaken reports whether name is the name of a package in the hook
+func (this *imports) isTaken(name string) bool {
+	return this.taken[name] != ""
+}
+
+// list returns used imports sorted by path
+func (this *imports) list() []Import {
+	list := make([]Import, 0, len(this.used))
+
+	for path := range this.used {
+		imp := Import{Path: path}
+		if this.names[path] != this.declared[path] {
+			imp.Name = this.names[path]
+		}
+
+		list = append(list, imp)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Path < list[j].Path
+	})
+
+	return list
+}
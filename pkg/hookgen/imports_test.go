@@ -0,0 +1,76 @@
+package hookgen
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_imports_list(t *testing.T) {
+	type fields struct {
+		locals []string
+	}
+	type args struct {
+		pkgs [][2]string
+	}
+	tests := []struct {
+		name      string
+		fields    fields
+		args      args
+		wantNames []string
+		want      []Import
+	}{
+		{
+			name:   "imports assign unique names to packages with the same name",
+			fields: fields{},
+			args: args{
+				pkgs: [][2]string{{"foo/log", "log"}, {"bar/log", "log"}, {"foo/log", "log"}, {"baz/log", "log"}},
+			},
+			wantNames: []string{"log", "log1", "log", "log2"},
+			want: []Import{
+				{Name: "log1", Path: "bar/log"},
+				{Name: "log2", Path: "baz/log"},
+				{Path: "foo/log"},
+			},
+		},
+		{
+			name: "imports don't qualify local packages",
+			fields: fields{
+				locals: []string{"foo/log"},
+			},
+			args: args{
+				pkgs: [][2]string{{"foo/log", "log"}, {"bar/log", "log"}},
+			},
+			wantNames: []string{"", "log"},
+			want: []Import{
+				{Path: "bar/log"},
+			},
+		},
+		{
+			name:   "imports keep names of packages imported by template",
+			fields: fields{},
+			args: args{
+				pkgs: [][2]string{{"example.com/sync", "sync"}, {"sync", "sync"}},
+			},
+			wantNames: []string{"sync1", "sync"},
+			want: []Import{
+				{Name: "sync1", Path: "example.com/sync"},
+				{Path: "sync"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			this := newImports(tt.fields.locals...)
+			names := []string{}
+			for _, pkg := range tt.args.pkgs {
+				names = append(names, this.add(pkg[0], pkg[1]))
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("imports.add() = %v, want %v", names, tt.wantNames)
+			}
+			if got := this.list(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("imports.list() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,11 @@
 package instance
 
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
 type (
 	Struct struct{}
 
@@ -18,4 +24,50 @@ type (
 	Interface3 interface {
 		Method(i int, s Struct)
 	}
+
+	Interface4 interface {
+		Start(s string)
+		Stop()
+	}
+
+	Interface5 interface {
+		Check() bool
+		Close() error
+	}
+
+	Interface6 interface {
+		Notify(context.Context)
+		Shutdown(ctx context.Context) error
+	}
+
+	Interface7 interface {
+		io.Closer
+		Flush() error
+	}
+
+	Interface8 interface {
+		fmt.Stringer
+		io.WriterTo
+	}
+
+	Interface9 interface {
+		~int
+		String() string
+	}
+
+	Interface10[K comparable, V fmt.Stringer] interface {
+		Set(key K, values ...V)
+	}
+
+	Interface11 interface {
+		Log(list []string, log string, r0 int, sync bool, arg5 int, _ int) (err error)
+	}
+
+	Interface12 interface {
+		On(hook int, s string)
+	}
+
+	Interface13[T any] interface {
+		Get() T
+	}
 )
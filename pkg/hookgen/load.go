@@ -0,0 +1,259 @@
+package hookgen
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+
+	"github.com/axard/things/pkg/resource"
+	"golang.org/x/tools/go/packages"
+)
+
+// Interface describes the source interface loaded from its package
+type Interface struct {
+	PkgPath string
+	PkgName string
+	Name    string
+
+	// Type is the type of the interface, generic interface is instantiated
+	// if it's loaded with type arguments
+	Type types.Type
+	// Pos is the position of the declaration of the interface
+	Pos     token.Position
+	Methods []InterfaceMethod
+	Docs    Docs
+
+	// Package is the package declaring the interface
+	Package *packages.Package
+}
+
+// InterfaceMethod describes the method of the source interface including
+// methods of embedded interfaces
+type InterfaceMethod struct {
+	Name      string
+	Signature *types.Signature
+	Pos       token.Position
+}
+
+// Src returns the source of the hook for Hookgen.Src like
+// "import/path.Name".
+func (this *Interface) Src() string {
+	return this.PkgPath + "." + this.Name
+}
+
+// Load loads the interface with the name from the package matching
+// pattern, it's a directory or an import path. Generic interface is
+// instantiated if name has type arguments like "Listener[pkg.Event]".
+func Load(pattern, name string) (*Interface, error) {
+	ifaces, err := LoadAll([]string{pattern + "." + name})
+	if err != nil {
+		return nil, err
+	}
+
+	return ifaces[0], nil
+}
+
+// LoadAll loads interfaces by sources like "/path/to/package.Name" or
+// "import/path.Name[int]", every package is loaded once by one call of
+// packages.Load.
+func LoadAll(srcs []string) ([]*Interface, error) {
+	pkgs := make([]string, 0, len(srcs))
+	for _, src := range srcs {
+		pkgs = append(pkgs, resource.Package(src))
+	}
+
+	ps, err := loadPackages(pkgs)
+	if err != nil {
+		return nil, err
+	}
+
+	ifaces := make([]*Interface, 0, len(srcs))
+	for i, src := range srcs {
+		name := resource.Object(src)
+		if args := resource.TypeArgs(src); args != nil {
+			name += "[" + strings.Join(args, ", ") + "]"
+		}
+
+		iface, err := LookupInterface(ps[i], name)
+		if err != nil {
+			return nil, err
+		}
+
+		ifaces = append(ifaces, iface)
+	}
+
+	return ifaces, nil
+}
+
+// LookupInterface finds the interface with the name in the loaded package,
+// the package must be loaded with types and syntax. Generic interface is
+// instantiated if name has type arguments.
+func LookupInterface(p *packages.Package, name string) (*Interface, error) {
+	if len(p.Errors) != 0 {
+		return nil, newPackageLoadError(p)
+	}
+
+	args := resource.TypeArgs(name)
+	if i := strings.Index(name, "["); i >= 0 {
+		name = name[:i]
+	}
+
+	obj := p.Types.Scope().Lookup(name)
+	if obj == nil {
+		return nil, &NotFoundError{Pkg: p.PkgPath, Name: name}
+	}
+
+	if !types.IsInterface(obj.Type()) {
+		return nil, &NotInterfaceError{
+			Name: name,
+			Type: obj.Type().String(),
+			Pos:  p.Fset.Position(obj.Pos()),
+		}
+	}
+
+	t := obj.Type()
+	if args != nil {
+		var err error
+		if t, err = instantiate(p, t, args); err != nil {
+			return nil, fmt.Errorf("%s.%s: %s", p.PkgPath, name, err)
+		}
+	}
+
+	iface := &Interface{
+		PkgPath: p.PkgPath,
+		PkgName: p.Name,
+		Name:    name,
+		Type:    t,
+		Pos:     p.Fset.Position(obj.Pos()),
+		Docs:    InterfaceDocs(p.Syntax, name),
+		Package: p,
+	}
+
+	it := t.Underlying().(*types.Interface)
+	for i := 0; i < it.NumMethods(); i++ {
+		m := it.Method(i)
+
+		iface.Methods = append(iface.Methods, InterfaceMethod{
+			Name:      m.Name(),
+			Signature: m.Type().(*types.Signature),
+			Pos:       p.Fset.Position(m.Pos()),
+		})
+	}
+
+	return iface, nil
+}
+
+// instantiate instantiates generic interface with type arguments, they are
+// evaluated like in the source package, so types of other packages are
+// qualified with names of packages imported by it
+func instantiate(p *packages.Package, t types.Type, args []string) (types.Type, error) {
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || named.TypeParams().Len() == 0 {
+		return nil, fmt.Errorf("'%s' isn't generic, it can't have type arguments", t.String())
+	}
+
+	env := types.NewPackage(p.PkgPath, p.Name)
+
+	for _, name := range p.Types.Scope().Names() {
+		env.Scope().Insert(p.Types.Scope().Lookup(name))
+	}
+
+	env.Scope().Insert(types.NewPkgName(token.NoPos, env, p.Name, p.Types))
+
+	for _, imported := range p.Types.Imports() {
+		env.Scope().Insert(types.NewPkgName(token.NoPos, env, imported.Name(), imported))
+	}
+
+	targs := make([]types.Type, 0, len(args))
+	for _, arg := range args {
+		tv, err := types.Eval(p.Fset, env, token.NoPos, arg)
+		if err != nil {
+			return nil, fmt.Errorf("type argument '%s': %s", arg, err)
+		}
+
+		if !tv.IsType() {
+			return nil, fmt.Errorf("type argument '%s' isn't a type", arg)
+		}
+
+		targs = append(targs, tv.Type)
+	}
+
+	return types.Instantiate(nil, named, targs, true)
+}
+
+// loadMode is the mode of loading packages declaring interfaces
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedModule |
+	packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo
+
+// loadPackages returns packages in the same order as pkgs, every one is a
+// directory or an import path
+func loadPackages(pkgs []string) ([]*packages.Package, error) {
+	keys := make([]string, 0, len(pkgs))
+	patterns := []string{}
+	seen := map[string]bool{}
+
+	for _, pkg := range pkgs {
+		key := pkg
+
+		if resource.IsLocal(pkg) {
+			abs, err := filepath.Abs(pkg)
+			if err != nil {
+				return nil, err
+			}
+
+			key = abs
+		}
+
+		keys = append(keys, key)
+
+		if !seen[key] {
+			seen[key] = true
+			patterns = append(patterns, key)
+		}
+	}
+
+	if len(patterns) == 0 {
+		return []*packages.Package{}, nil
+	}
+
+	ps, err := packages.Load(&packages.Config{Mode: loadMode}, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := map[string][]*packages.Package{}
+	for _, p := range ps {
+		byKey[p.PkgPath] = append(byKey[p.PkgPath], p)
+
+		if len(p.GoFiles) != 0 {
+			dir := filepath.Dir(p.GoFiles[0])
+			if dir != p.PkgPath {
+				byKey[dir] = append(byKey[dir], p)
+			}
+		} else if p.ID != p.PkgPath {
+			byKey[p.ID] = append(byKey[p.ID], p)
+		}
+	}
+
+	result := make([]*packages.Package, 0, len(pkgs))
+	for i, pkg := range pkgs {
+		found := byKey[keys[i]]
+
+		if len(found) == 0 {
+			return nil, &NotFoundError{Pkg: pkg}
+		}
+
+		if len(found) > 1 {
+			return nil, &PackageLoadError{
+				Pkg:    pkg,
+				Errors: []PositionError{{Msg: fmt.Sprintf("too many packages in '%s'", pkg)}},
+			}
+		}
+
+		result = append(result, found[0])
+	}
+
+	return result, nil
+}
@@ -0,0 +1,115 @@
+package hookgen
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	type args struct {
+		pattern string
+		name    string
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantPkgPath string
+		wantType    string
+		wantMethods []string
+		wantErr     bool
+	}{
+		{
+			name: "Load() loads interface with methods of embedded ones",
+			args: args{
+				pattern: "./internal/instance",
+				name:    "Interface7",
+			},
+			wantPkgPath: "github.com/axard/things/pkg/hookgen/internal/instance",
+			wantType:    "github.com/axard/things/pkg/hookgen/internal/instance.Interface7",
+			wantMethods: []string{"Close", "Flush"},
+			wantErr:     false,
+		},
+		{
+			name: "Load() instantiates generic interface",
+			args: args{
+				pattern: "github.com/axard/things/pkg/hookgen/internal/instance",
+				name:    "Interface10[string, fmt.Stringer]",
+			},
+			wantPkgPath: "github.com/axard/things/pkg/hookgen/internal/instance",
+			wantType:    "github.com/axard/things/pkg/hookgen/internal/instance.Interface10[string, fmt.Stringer]",
+			wantMethods: []string{"Set"},
+			wantErr:     false,
+		},
+		{
+			name: "Load() fails on missing interface",
+			args: args{
+				pattern: "./internal/instance",
+				name:    "Missing",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Load() fails on type which isn't interface",
+			args: args{
+				pattern: "./internal/instance",
+				name:    "Struct",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.args.pattern, tt.args.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got.PkgPath != tt.wantPkgPath {
+				t.Errorf("Load().PkgPath = %v, want %v", got.PkgPath, tt.wantPkgPath)
+			}
+			if got.Type.String() != tt.wantType {
+				t.Errorf("Load().Type = %v, want %v", got.Type, tt.wantType)
+			}
+			if filepath.Base(got.Pos.Filename) != "instance.go" || got.Pos.Line == 0 {
+				t.Errorf("Load().Pos = %v, want position in instance.go", got.Pos)
+			}
+
+			methods := []string{}
+			for _, m := range got.Methods {
+				methods = append(methods, m.Name)
+			}
+			if !reflect.DeepEqual(methods, tt.wantMethods) {
+				t.Errorf("Load().Methods = %v, want %v", methods, tt.wantMethods)
+			}
+		})
+	}
+}
+
+func TestHookgen_GenerateInterface(t *testing.T) {
+	iface, err := Load("./internal/instance", "Interface4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	this := &Hookgen{Dst: "github.com/axard/things/pkg/hookgen/internal/instance.Hook"}
+
+	got, err := this.GenerateInterface(iface)
+	if err != nil {
+		t.Fatalf("Hookgen.GenerateInterface() error = %v", err)
+	}
+
+	for _, want := range []string{"package instance", "type Hook struct", "func (this *Hook) Start(s string)"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("Hookgen.GenerateInterface() = %s, want %s in it", got, want)
+		}
+	}
+
+	if this.Src != "" {
+		t.Errorf("Hookgen.GenerateInterface() changed Src to %v", this.Src)
+	}
+}
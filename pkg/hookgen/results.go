@@ -0,0 +1,169 @@
+package hookgen
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// Strategies of aggregation results returned by hooked items into results
+// of hook method.
+const (
+	// ResultsFirstError stops on first item returned non-nil error.
+	ResultsFirstError = "first-error"
+	// ResultsAllErrors calls every item and collects returned errors.
+	ResultsAllErrors = "all-errors"
+	// ResultsFirst stops on first item returned non-zero results.
+	ResultsFirst = "first"
+	// ResultsLast returns results of the last called item.
+	ResultsLast = "last"
+	// ResultsAll returns results of every item as slices.
+	ResultsAll = "all"
+)
+
+// Result describes one result of a method of the source interface.
+type Result struct {
+	// Name of the result of hook method
+	Name string
+	// Var is the name of the variable for the result of item method
+	Var string
+	// Type of the result of item method
+	Type string
+	// NonZero is an expression true if Var is not zero value
+	NonZero string
+
+	IsError bool
+}
+
+// strategies maps method names to result strategies, key "" holds the
+// strategy for methods which aren't listed.
+type strategies map[string]string
+
+// parseStrategies parses spec like "last,Close=all-errors"
+func parseStrategies(spec string) (strategies, error) {
+	ss := strategies{}
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, strategy := "", item
+		if i := strings.Index(item, "="); i >= 0 {
+			name, strategy = strings.TrimSpace(item[:i]), strings.TrimSpace(item[i+1:])
+		}
+
+		switch strategy {
+		case ResultsFirstError, ResultsAllErrors, ResultsFirst, ResultsLast, ResultsAll:
+		default:
+			return nil, fmt.Errorf("unknown result strategy '%s'", strategy)
+		}
+
+		ss[name] = strategy
+	}
+
+	return ss, nil
+}
+
+// check checks methods named in strategies are methods of the interface
+func (this strategies) check(iface *types.Interface) error {
+	names := make([]string, 0, len(this))
+	for name := range this {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+
+		found := false
+		for i := 0; i < iface.NumMethods(); i++ {
+			if iface.Method(i).Name() == name {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("unknown method '%s' in result strategies", name)
+		}
+	}
+
+	return nil
+}
+
+func (this strategies) strategy(meth *types.Func) (string, error) {
+	res := meth.Type().(*types.Signature).Results()
+	if res.Len() == 0 {
+		return "", nil
+	}
+
+	returnsError := isError(res.At(res.Len() - 1).Type())
+
+	strategy, ok := this[meth.Name()]
+	if !ok {
+		strategy, ok = this[""]
+	}
+
+	if !ok {
+		if returnsError {
+			return ResultsFirstError, nil
+		}
+
+		return ResultsLast, nil
+	}
+
+	if (strategy == ResultsFirstError || strategy == ResultsAllErrors) && !returnsError {
+		return "", &UnsupportedSignatureError{
+			Method: meth.Name(),
+			Reason: fmt.Sprintf("must return error for result strategy '%s'", strategy),
+		}
+	}
+
+	return strategy, nil
+}
+
+func isError(t types.Type) bool {
+	return types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+// nonZero returns an expression which is true if v of type t isn't zero
+// value.
+func nonZero(v string, t types.Type, qualifier types.Qualifier) (string, error) {
+	// the underlying type of type parameter is its constraint, but its
+	// value may be of any type satisfying it
+	if _, ok := types.Unalias(t).(*types.TypeParam); ok {
+		return "", fmt.Errorf("can't compare type parameter '%s' with zero value", t.String())
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return v, nil
+		case u.Info()&types.IsString != 0:
+			return v + ` != ""`, nil
+		case u.Info()&types.IsNumeric != 0:
+			return v + " != 0", nil
+		default:
+			return v + " != nil", nil
+		}
+
+	case *types.Pointer, *types.Interface, *types.Slice, *types.Map, *types.Chan, *types.Signature:
+		return v + " != nil", nil
+
+	case *types.Struct, *types.Array:
+		if !types.Comparable(t) {
+			break
+		}
+
+		return v + " != (" + types.TypeString(t, qualifier) + "{})", nil
+	}
+
+	return "", fmt.Errorf("can't compare '%s' with zero value", t.String())
+}
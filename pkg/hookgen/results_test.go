@@ -0,0 +1,106 @@
+package hookgen
+
+import (
+	"go/types"
+	"reflect"
+	"testing"
+)
+
+func Test_parseStrategies(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    strategies
+		wantErr bool
+	}{
+		{
+			name: "empty spec",
+			spec: "",
+			want: strategies{},
+		},
+		{
+			name: "default strategy",
+			spec: "last",
+			want: strategies{"": ResultsLast},
+		},
+		{
+			name: "default and per-method strategies",
+			spec: "first, Close = all-errors,Check=all",
+			want: strategies{"": ResultsFirst, "Close": ResultsAllErrors, "Check": ResultsAll},
+		},
+		{
+			name:    "unknown strategy",
+			spec:    "Close=any",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStrategies(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseStrategies() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseStrategies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_nonZero(t *testing.T) {
+	tests := []struct {
+		name    string
+		t       types.Type
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "bool",
+			t:    types.Typ[types.Bool],
+			want: "v",
+		},
+		{
+			name: "string",
+			t:    types.Typ[types.String],
+			want: `v != ""`,
+		},
+		{
+			name: "int",
+			t:    types.Typ[types.Int],
+			want: "v != 0",
+		},
+		{
+			name: "slice",
+			t:    types.NewSlice(types.Typ[types.Int]),
+			want: "v != nil",
+		},
+		{
+			name: "struct",
+			t:    types.NewStruct([]*types.Var{types.NewField(0, nil, "A", types.Typ[types.Int], false)}, nil),
+			want: "v != (struct{A int}{})",
+		},
+		{
+			name:    "type parameter",
+			t:       types.NewTypeParam(types.NewTypeName(0, nil, "T", nil), types.NewInterfaceType(nil, nil)),
+			wantErr: true,
+		},
+		{
+			name:    "not comparable struct",
+			t:       types.NewStruct([]*types.Var{types.NewField(0, nil, "A", types.NewSlice(types.Typ[types.Int]), false)}, nil),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nonZero("v", tt.t, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("nonZero() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("nonZero() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
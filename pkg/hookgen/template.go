@@ -1,9 +1,13 @@
 package hookgen
 
 import (
+	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"text/template"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Use only spaces for indentation
@@ -14,90 +18,1112 @@ package {{.PackageName}}
 
 import (
     {{- range .Imports }}
-    "{{.}}"
+    {{with .Name}}{{.}} {{end}}"{{.Path}}"
     {{- end }}
-    {{- with .Safe}}
+    {{- if and (or .Safe .Recorder (eq .Dispatch "parallel" "async")) (not (.Imported "sync"))}}
     "sync"
     {{- end}}
+    {{- if and .Safe (not (.Imported "sync/atomic"))}}
+    "sync/atomic"
+    {{- end}}
+    {{- if and .Recorder (not (.Imported "time"))}}
+    "time"
+    {{- end}}
 )
 
-type {{.HookName}} struct {
-    list []*hooked
-    {{- with .Safe}}
+{{comment .HookDoc}}
+type {{.HookName}}{{.TypeParams}} struct {
+    {{- if and .Safe (eq .Sync "atomic")}}
+    list atomic.Value
     m sync.Mutex
+    {{- else if .Safe}}
+    list []*hooked{{.HookName}}{{.TypeArgs}}
+    m sync.{{if eq .Sync "rwmutex"}}RWMutex{{else}}Mutex{{end}}
+    {{- else}}
+    list []*hooked{{.HookName}}{{.TypeArgs}}
+    {{- end}}
+    {{- if eq .Dispatch "async"}}
+
+    queue   chan event{{.HookName}}{{.TypeArgs}}
+    queueM  sync.RWMutex
+    closing chan struct{}
+    done    chan struct{}
+    {{- end}}
+    {{- if .Once}}
+
+    fired  map[string]bool
+    replay []func({{.InterfaceName}})
+    {{- end}}
+    {{- if .Recover}}
+
+    // OnPanic is called with the item and the value recovered from its
+    // panic, the panic is ignored if OnPanic is nil
+    OnPanic func(item {{.InterfaceName}}, recovered interface{})
     {{- end}}
 }
 
-type hooked struct {
+type hooked{{.HookName}}{{.TypeParams}} struct {
     {{.InterfaceName}}
+    priority int
+    once     bool
+    fired    uint32
 }
 
-type Cancel = func()
+// {{.HookName}}Cancel removes the registered item
+type {{.HookName}}Cancel = func()
 
-func (this *{{.HookName}}) Append(item {{.InterfaceName}}) Cancel {
+// Append registers item with zero priority
+func (this *{{.HookName}}{{.TypeArgs}}) Append(item {{.InterfaceName}}) {{.HookName}}Cancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in {{if eq .Order "lifo"}}reverse {{end}}order of
+// registration
+func (this *{{.HookName}}{{.TypeArgs}}) AppendWithPriority(item {{.InterfaceName}}, priority int) {{.HookName}}Cancel {
+    return this.insert(item, priority, false, false)
+}
+
+// Prepend registers item to be called before all registered items
+func (this *{{.HookName}}{{.TypeArgs}}) Prepend(item {{.InterfaceName}}) {{.HookName}}Cancel {
+    return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *{{.HookName}}{{.TypeArgs}}) AppendOnce(item {{.InterfaceName}}) {{.HookName}}Cancel {
+    return this.insert(item, 0, false, true)
+}
+
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *{{.HookName}}{{.TypeArgs}}) insert(item {{.InterfaceName}}, priority int, first, once bool) {{.HookName}}Cancel {
     {{with .Safe -}}
     this.m.Lock()
+    {{- if not $.Once}}
     defer this.m.Unlock()
+    {{- end}}
 
     {{end -}}
-    hooked := &hooked{item}
-    this.list = append(this.list, hooked)
+    {{if and .Safe (eq .Sync "atomic") -}}
+    list, _ := this.list.Load().([]*hooked{{.HookName}}{{.TypeArgs}})
+    {{- else -}}
+    list := this.list
+    {{- end}}
 
-    return func() { this.remove(hooked) }
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority {{if eq .Order "lifo"}}>{{else}}>={{end}} priority {
+            i++
+        }
+    }
+
+    entry := &hooked{{.HookName}}{{.TypeArgs}}{item, priority, once, 0}
+
+    rest := make([]*hooked{{.HookName}}{{.TypeArgs}}, 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    {{- if and .Safe (eq .Sync "atomic")}}
+    this.list.Store(rest)
+    {{- else}}
+    this.list = rest
+    {{- end}}
+    {{- if .Once}}
+
+    replay := this.replay
+    {{- if .Safe}}
+    this.m.Unlock()
+    {{- end}}
+
+    // calls made before the item is appended are replayed for it
+    for _, call := range replay {
+        if !this.claim(entry) {
+            break
+        }
+
+        {{if .Recover -}}
+        this.guard(item, func() { call(item) })
+        {{- else -}}
+        call(item)
+        {{- end}}
+    }
+    {{- end}}
+
+    return func() { this.remove(entry) }
 }
 
-func (this *{{.HookName}}) remove(hooked *hooked) {
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *{{.HookName}}{{.TypeArgs}}) remove(entry *hooked{{.HookName}}{{.TypeArgs}}) {
     {{with .Safe -}}
     this.m.Lock()
     defer this.m.Unlock()
 
     {{end -}}
-    for i := range this.list {
-        if this.list[i] == hooked {
-            this.list = append(this.list[:i], this.list[i+1:]...)
+    {{if and .Safe (eq .Sync "atomic") -}}
+    list, _ := this.list.Load().([]*hooked{{.HookName}}{{.TypeArgs}})
+    {{- else -}}
+    list := this.list
+    {{- end}}
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hooked{{.HookName}}{{.TypeArgs}}, 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            {{- if and .Safe (eq .Sync "atomic")}}
+            this.list.Store(rest)
+            {{- else}}
+            this.list = rest
+            {{- end}}
             break
         }
     }
 }
 
-func (this *{{.HookName}}) {{.MethodName}}({{join .MethodDeclArgs ", "}}) {
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *{{.HookName}}{{.TypeArgs}}) claim(entry *hooked{{.HookName}}{{.TypeArgs}}) bool {
+    if !entry.once {
+        return true
+    }
+    {{- if .Safe}}
+
+    if !atomic.CompareAndSwapUint32(&entry.fired, 0, 1) {
+        return false
+    }
+    {{- else}}
+
+    if entry.fired != 0 {
+        return false
+    }
+
+    entry.fired = 1
+    {{- end}}
+
+    this.remove(entry)
+
+    return true
+}
+{{- if eq .Dispatch "parallel"}}
+
+// claimed returns the items which can be called
+func (this *{{.HookName}}{{.TypeArgs}}) claimed(list []*hooked{{.HookName}}{{.TypeArgs}}) []*hooked{{.HookName}}{{.TypeArgs}} {
+    rest := make([]*hooked{{.HookName}}{{.TypeArgs}}, 0, len(list))
+    for _, entry := range list {
+        if this.claim(entry) {
+            rest = append(rest, entry)
+        }
+    }
+
+    return rest
+}
+{{- end}}
+{{- if .Once}}
+
+// record records the first call of the method to replay it for items
+// appended later, it returns the items to call or false if the method has
+// been called before
+func (this *{{.HookName}}{{.TypeArgs}}) record(method string, call func({{.InterfaceName}})) ([]*hooked{{.HookName}}{{.TypeArgs}}, bool) {
     {{with .Safe -}}
     this.m.Lock()
     defer this.m.Unlock()
 
     {{end -}}
-    for _, hooked := range this.list {
-        hooked.{{.MethodName}}({{join .MethodCallArgs ", "}})
+    if this.fired[method] {
+        return nil, false
+    }
+
+    if this.fired == nil {
+        this.fired = map[string]bool{}
+    }
+
+    this.fired[method] = true
+    this.replay = append(this.replay, call)
+    {{- if and .Safe (eq .Sync "atomic")}}
+
+    list, _ := this.list.Load().([]*hooked{{.HookName}}{{.TypeArgs}})
+
+    return list, true
+    {{- else}}
+
+    return this.list, true
+    {{- end}}
+}
+{{- end}}
+{{- if .Recover}}
+
+// guard calls fn, a panic of the item in it doesn't stop other items
+func (this *{{.HookName}}{{.TypeArgs}}) guard(item {{.InterfaceName}}, fn func()) {
+    defer this.rescue(item)
+
+    fn()
+}
+
+// rescue reports the recovered panic of the item to OnPanic, it must be
+// deferred
+func (this *{{.HookName}}{{.TypeArgs}}) rescue(item {{.InterfaceName}}) {
+    recovered := recover()
+    if recovered == nil {
+        return
+    }
+
+    if this.OnPanic != nil {
+        this.OnPanic(item, recovered)
+    }
+}
+{{- end}}
+{{- if .Safe}}
+
+// items returns the list of hooked items which is never changed in place
+func (this *{{.HookName}}{{.TypeArgs}}) items() []*hooked{{.HookName}}{{.TypeArgs}} {
+    {{- if eq .Sync "atomic"}}
+    list, _ := this.list.Load().([]*hooked{{.HookName}}{{.TypeArgs}})
+
+    return list
+    {{- else if eq .Sync "rwmutex"}}
+    this.m.RLock()
+    defer this.m.RUnlock()
+
+    return this.list
+    {{- else}}
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    return this.list
+    {{- end}}
+}
+{{- end}}
+
+{{- with .Uses "all-errors"}}
+
+type {{$.HookName}}Errors []error
+
+func (this {{$.HookName}}Errors) Error() string {
+    msg := ""
+    for i, err := range this {
+        if i > 0 {
+            msg += "; "
+        }
+        msg += err.Error()
+    }
+
+    return msg
+}
+
+func (this {{$.HookName}}Errors) Unwrap() []error {
+    return this
+}
+
+func (this {{$.HookName}}Errors) err() error {
+    if len(this) == 0 {
+        return nil
+    }
+
+    return this
+}
+{{- end}}
+{{- if eq .Dispatch "async"}}
+
+type event{{.HookName}}{{.TypeParams}} interface {
+    dispatch(hook *{{.HookName}}{{.TypeArgs}})
+}
+
+// Start runs the worker calling hooked items, calls made before Start or
+// after Close are dropped.
+func (this *{{.HookName}}{{.TypeArgs}}) Start() {
+    this.queueM.Lock()
+    defer this.queueM.Unlock()
+
+    if this.queue != nil {
+        return
     }
+
+    queue := make(chan event{{.HookName}}{{.TypeArgs}}, {{.QueueSize}})
+    closing := make(chan struct{})
+    done := make(chan struct{})
+
+    go func() {
+        defer close(done)
+
+        for {
+            select {
+            case event := <-queue:
+                event.dispatch(this)
+            case <-closing:
+                for {
+                    select {
+                    case event := <-queue:
+                        event.dispatch(this)
+                    default:
+                        return
+                    }
+                }
+            }
+        }
+    }()
+
+    this.queue, this.closing, this.done = queue, closing, done
+}
+
+// Close stops accepting calls and waits until the queued ones are done.
+// Items must not call it, it would wait for the item calling it forever,
+// they may call it in a new goroutine like "go hook.Close()".
+func (this *{{.HookName}}{{.TypeArgs}}) Close() {
+    this.queueM.Lock()
+    closing, done := this.closing, this.done
+    this.queue, this.closing, this.done = nil, nil, nil
+    this.queueM.Unlock()
+
+    if closing == nil {
+        return
+    }
+
+    close(closing)
+    <-done
+}
+
+// enqueue doesn't hold the lock while it sends the call, the queue is never
+// closed and the call blocked on it gives up when the hook is closed
+func (this *{{.HookName}}{{.TypeArgs}}) enqueue(event event{{.HookName}}{{.TypeArgs}}) {
+    this.queueM.RLock()
+    {{- if eq .Overflow "drop-newest" "drop-oldest"}}
+    queue := this.queue
+    {{- else}}
+    queue, closing := this.queue, this.closing
+    {{- end}}
+    this.queueM.RUnlock()
+
+    if queue == nil {
+        return
+    }
+    {{- if eq .Overflow "drop-newest"}}
+
+    select {
+    case queue <- event:
+    default:
+    }
+    {{- else if eq .Overflow "drop-oldest"}}
+
+    for {
+        select {
+        case queue <- event:
+            return
+        default:
+        }
+
+        select {
+        case <-queue:
+        default:
+        }
+    }
+    {{- else}}
+
+    select {
+    case queue <- event:
+    case <-closing:
+    }
+    {{- end}}
+}
+{{- end}}
+{{- range .Methods}}
+{{- if eq $.Dispatch "async"}}
+
+type event{{$.HookName}}{{.Name}}{{$.TypeParams}} struct {
+    {{- range .Params}}
+    {{.Name}} {{.Type}}
+    {{- end}}
+{{- with .Params}}
+{{end -}}
+}
+
+func (this event{{$.HookName}}{{.Name}}{{$.TypeArgs}}) dispatch(hook *{{$.HookName}}{{$.TypeArgs}}) {
+    hook.fire{{.Name}}({{range $i, $p := .Params}}{{if $i}}, {{end}}this.{{$p.Name}}{{if $p.Variadic}}...{{end}}{{end}})
 }
+
+{{comment ($.MethodDoc .)}}
+func (this *{{$.HookName}}{{$.TypeArgs}}) {{.Name}}({{join .DeclArgs ", "}}) {
+    this.enqueue(event{{$.HookName}}{{.Name}}{{$.TypeArgs}}{ {{- range $i, $p := .Params}}{{if $i}}, {{end}}{{$p.Name}}{{end -}} })
+}
+{{- end}}
+
+{{if ne $.Dispatch "async"}}{{comment ($.MethodDoc .)}}
+{{end -}}
+func (this *{{$.HookName}}{{$.TypeArgs}}) {{if eq $.Dispatch "async"}}fire{{end}}{{.Name}}({{join .DeclArgs ", "}}){{with .DeclResults}} ({{join . ", "}}){{end}} {
+    {{- $list := "this.list"}}
+    {{if $.Once -}}
+    {{- $list = "list" -}}
+    list, ok := this.record("{{.Name}}", func(item {{$.InterfaceName}}) {
+        item.{{.Name}}({{join .CallArgs ", "}})
+    })
+    if !ok {
+        return
+    }
+
+    {{else if $.Safe -}}
+    {{- $list = "list" -}}
+    list := this.items()
+
+    {{end -}}
+    {{if eq .Strategy "all-errors" -}}
+    var errs {{$.HookName}}Errors
+
+    {{end -}}
+    {{if eq $.Dispatch "parallel" -}}
+    {{if eq $list "list"}}list = {{else}}list := {{end}}this.claimed({{$list}})
+    {{- $list = "list"}}
+
+    {{with .Results -}}
+    results := make([]struct {
+        {{- range .}}
+        {{.Var}} {{.Type}}
+        {{- end}}
+    }, len({{$list}}))
+    {{end -}}
+    wg := sync.WaitGroup{}
+    {{- with $.Concurrency}}
+    sem := make(chan struct{}, {{.}})
+    {{- end}}
+    {{- if .Context}}
+    {{- if .Results}}
+    started := 0
+    {{- end}}
+    var ctxErr error
+    {{- end}}
+
+    for {{if .Results}}i{{else}}_{{end}}, hooked := range {{$list}} {
+        {{if .Context -}}
+        if ctxErr = {{.Context}}.Err(); ctxErr != nil {
+            break
+        }
+
+        {{if $.Concurrency -}}
+        select {
+        case sem <- struct{}{}:
+        case <-{{.Context}}.Done():
+            ctxErr = {{.Context}}.Err()
+        }
+
+        if ctxErr != nil {
+            break
+        }
+
+        {{end -}}
+        {{if .Results -}}
+        started++
+        {{end -}}
+        {{else if $.Concurrency -}}
+        sem <- struct{}{}
+        {{end -}}
+        {{if .Results}}i, {{end}}hooked := {{if .Results}}i, {{end}}hooked
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            {{- if $.Concurrency}}
+            defer func() { <-sem }()
+            {{- end}}
+            {{- if $.Recover}}
+            defer this.rescue(hooked.{{$.ItemField}})
+            {{- end}}
+
+            {{range $i, $r := .Results}}{{if $i}}, {{end}}results[i].{{$r.Var}}{{end}}{{if .Results}} = {{end}}hooked.{{.Name}}({{join .CallArgs ", "}})
+        }()
+    }
+
+    wg.Wait()
+    {{- if .Results}}
+
+    for _, result := range results{{if .Context}}[:started]{{end}} {
+        {{join .ResultVars ", "}} := {{range $i, $r := .Results}}{{if $i}}, {{end}}result.{{$r.Var}}{{end}}
+        {{- template "aggregate" .}}
+    }
+    {{- end}}
+    {{- if and .Context (eq .Strategy "all-errors")}}
+
+    if ctxErr != nil {
+        errs = append(errs, ctxErr)
+    }
+    {{- else if and .Context .ErrorResult (ne .Strategy "all")}}
+
+    if ctxErr != nil {
+        {{.ErrorResult.Name}} = ctxErr
+    }
+    {{- end}}
+    {{- else -}}
+    for _, hooked := range {{$list}} {
+        {{if .Context -}}
+        if err := {{.Context}}.Err(); err != nil {
+            {{- if eq .Strategy "all-errors"}}
+            errs = append(errs, err)
+            break
+            {{- else if and .ErrorResult (ne .Strategy "all")}}
+            {{.ErrorResult.Name}} = err
+            return
+            {{- else if .Results}}
+            break
+            {{- else}}
+            return
+            {{- end}}
+        }
+
+        {{end -}}
+        if !this.claim(hooked) {
+            continue
+        }
+
+        {{if $.Recover -}}
+        {{range .Results -}}
+        var {{.Var}} {{.Type}}
+        {{end -}}
+        this.guard(hooked.{{$.ItemField}}, func() {
+            {{with .ResultVars}}{{join . ", "}} = {{end}}hooked.{{.Name}}({{join .CallArgs ", "}})
+        })
+        {{- else -}}
+        {{with .ResultVars}}{{join . ", "}} := {{end}}hooked.{{.Name}}({{join .CallArgs ", "}})
+        {{- end}}
+        {{- template "aggregate" .}}
+    }
+    {{- end}}
+    {{- if .Results}}
+    {{- if eq .Strategy "all-errors"}}
+
+    {{.ErrorResult.Name}} = errs.err()
+    {{- end}}
+
+    return
+    {{- end}}
+}
+{{- end}}
+{{- if .Funcs}}
+{{- if eq (len .Methods) 1}}
+{{- with index .Methods 0}}
+
+// {{$.ItemField}}Func is an adapter to use a function as {{$.InterfaceName}}
+type {{$.ItemField}}Func{{$.TypeParams}} func({{join .DeclArgs ", "}}){{with .ItemDeclResults}} ({{join . ", "}}){{end}}
+
+func (this {{$.ItemField}}Func{{$.TypeArgs}}) {{.Name}}({{join .DeclArgs ", "}}){{with .ItemDeclResults}} ({{join . ", "}}){{end}} {
+    {{if .Results}}return {{end}}this({{join .CallArgs ", "}})
+}
+{{- end}}
+{{- else if .Methods}}
+
+// {{.ItemField}}Funcs is an adapter to use functions as {{.InterfaceName}},
+// methods without function do nothing and return zero values
+type {{.ItemField}}Funcs{{.TypeParams}} struct {
+    {{- range .Methods}}
+    On{{.Name}} func({{join .DeclArgs ", "}}){{with .ItemDeclResults}} ({{join . ", "}}){{end}}
+    {{- end}}
+}
+{{- range .Methods}}
+
+func (this {{$.ItemField}}Funcs{{$.TypeArgs}}) {{.Name}}({{join .DeclArgs ", "}}){{with .ItemDeclResults}} ({{join . ", "}}){{end}} {
+    if this.On{{.Name}} != nil {
+        {{if .Results}}return {{end}}this.On{{.Name}}({{join .CallArgs ", "}})
+    }
+    {{- if .Results}}
+
+    return
+    {{- end}}
+}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if .Recorder}}
+
+{{comment .RecorderDoc}}
+type {{.ItemField}}Recorder{{.TypeParams}} struct {
+    m       sync.Mutex
+    calls   []{{.ItemField}}Call{{.TypeArgs}}
+    changed chan struct{}
+}
+
+{{comment .CallDoc}}
+type {{.ItemField}}Call{{.TypeParams}} struct {
+    {{- range .Methods}}
+    {{.Name}} *{{$.ItemField}}{{.Name}}Call{{$.TypeArgs}}
+    {{- end}}
+}
+{{- range .Methods}}
+
+// {{$.ItemField}}{{.Name}}Call is arguments of the recorded call of {{.Name}}
+type {{$.ItemField}}{{.Name}}Call{{$.TypeParams}} struct {
+    {{- range .Params}}
+    {{.Field}} {{.Type}}
+    {{- end}}
+}
+{{- end}}
+{{- range .Methods}}
+
+func (this *{{$.ItemField}}Recorder{{$.TypeArgs}}) {{.Name}}({{join .DeclArgs ", "}}){{with .ItemDeclResults}} ({{join . ", "}}){{end}} {
+    this.record({{$.ItemField}}Call{{$.TypeArgs}}{ {{- .Name}}: &{{$.ItemField}}{{.Name}}Call{{$.TypeArgs}}{ {{- range $i, $p := .Params}}{{if $i}}, {{end}}{{$p.Field}}: {{$p.Name}}{{end -}} }})
+    {{- if .Results}}
+
+    return
+    {{- end}}
+}
+{{- end}}
+
+func (this *{{.ItemField}}Recorder{{.TypeArgs}}) record(call {{.ItemField}}Call{{.TypeArgs}}) {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    this.calls = append(this.calls, call)
+
+    // wake up callers of WaitForCalls
+    if this.changed != nil {
+        close(this.changed)
+        this.changed = nil
+    }
+}
+
+// Calls returns recorded calls in order they were made
+func (this *{{.ItemField}}Recorder{{.TypeArgs}}) Calls() []{{.ItemField}}Call{{.TypeArgs}} {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    return append([]{{.ItemField}}Call{{.TypeArgs}}{}, this.calls...)
+}
+
+// Reset forgets recorded calls
+func (this *{{.ItemField}}Recorder{{.TypeArgs}}) Reset() {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    this.calls = nil
+}
+
+// WaitForCalls waits until at least n calls are recorded, it returns false
+// if they aren't recorded before timeout
+func (this *{{.ItemField}}Recorder{{.TypeArgs}}) WaitForCalls(n int, timeout time.Duration) bool {
+    timer := time.NewTimer(timeout)
+    defer timer.Stop()
+
+    for {
+        this.m.Lock()
+        count := len(this.calls)
+        if this.changed == nil {
+            this.changed = make(chan struct{})
+        }
+        changed := this.changed
+        this.m.Unlock()
+
+        if count >= n {
+            return true
+        }
+
+        select {
+        case <-changed:
+        case <-timer.C:
+            return false
+        }
+    }
+}
+{{- end}}
+{{define "aggregate"}}
+    {{- if eq .Strategy "last"}}
+        {{join .ResultNames ", "}} = {{join .ResultVars ", "}}
+    {{- else if eq .Strategy "first-error"}}
+        {{join .ResultNames ", "}} = {{join .ResultVars ", "}}
+        if {{.ErrorResult.Var}} != nil {
+            return
+        }
+    {{- else if eq .Strategy "all-errors"}}
+        {{- range .Results}}
+        {{- if .IsError}}
+        if {{.Var}} != nil {
+            errs = append(errs, {{.Var}})
+        }
+        {{- else}}
+        {{.Name}} = {{.Var}}
+        {{- end}}
+        {{- end}}
+    {{- else if eq .Strategy "first"}}
+        if {{join .NonZeros " || "}} {
+            return {{join .ResultVars ", "}}
+        }
+    {{- else if eq .Strategy "all"}}
+        {{- range .Results}}
+        {{.Name}} = append({{.Name}}, {{.Var}})
+        {{- end}}
+    {{- end}}
+{{- end -}}
 `
 
 type HookTemplate struct {
-	Imports        []string
-	InterfaceName  string
-	HookName       string
-	PackageName    string
-	MethodName     string
-	MethodDeclArgs []string
-	MethodCallArgs []string
+	Imports       []Import
+	InterfaceName string
+	HookName      string
+	PackageName   string
+	Methods       []Method
+
+	// Doc is the comment of the source interface without comment markers
+	Doc string
+
+	// TypeParams and TypeArgs are type parameters of generic hook like
+	// "[K comparable, V any]" for declarations and "[K, V]" for uses, they
+	// are empty if the hook isn't generic
+	TypeParams string
+	TypeArgs   string
 
 	Safe bool
+	// Sync is the way the safe hook protects its list: mutex, rwmutex or
+	// atomic
+	Sync string
+
+	// Dispatch is the way items are called: sequential or parallel,
+	// Concurrency limits the number of parallel calls if it isn't zero
+	Dispatch    string
+	Concurrency int
+
+	// QueueSize is the size of the queue of async hook and Overflow is
+	// the policy for calls made when the queue is full
+	QueueSize int
+	Overflow  string
+
+	// Order of calls of items with the same priority: fifo or lifo
+	Order string
+
+	// Once hook calls every method only once and replays the calls for
+	// items appended later
+	Once bool
+
+	// Recover hook recovers panics of items and reports them to OnPanic,
+	// other items are called anyway
+	Recover bool
+
+	// Funcs adds the adapter to use functions as the interface: the func
+	// type for interface with one method and the struct of functions for
+	// the others
+	Funcs bool
+
+	// Recorder adds <Interface>Recorder recording calls of the interface
+	// for tests
+	Recorder bool
+}
+
+// Imported reports whether the package is in Imports.
+func (this HookTemplate) Imported(path string) bool {
+	for _, imp := range this.Imports {
+		if imp.Path == path {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ItemField returns the name of the interface embedded into hooked items.
+func (this HookTemplate) ItemField() string {
+	name := this.InterfaceName
+	if i := strings.Index(name, "["); i >= 0 {
+		name = name[:i]
+	}
+
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// HookDoc returns the comment of the hook describing the way it calls
+// items, the comment of the interface follows it.
+func (this HookTemplate) HookDoc() string {
+	sentences := []string{
+		fmt.Sprintf("%s implements %s by calling every registered item.", this.HookName, this.InterfaceName),
+	}
+
+	order := "in order of registration"
+	if this.Order == OrderLIFO {
+		order = "in reverse order of registration"
+	}
+
+	switch this.Dispatch {
+	case DispatchParallel:
+		limit := ""
+		if this.Concurrency > 0 {
+			limit = fmt.Sprintf(", at most %d at once", this.Concurrency)
+		}
+
+		sentences = append(sentences, fmt.Sprintf("Methods call items concurrently%s and wait for them.", limit))
+	case DispatchAsync:
+		overflow := "a call blocks while the queue is full until Close"
+		switch this.Overflow {
+		case OverflowDropNewest:
+			overflow = "a call is dropped while the queue is full"
+		case OverflowDropOldest:
+			overflow = "the oldest queued call is dropped while the queue is full"
+		}
+
+		sentences = append(sentences,
+			"Methods queue calls and return at once, the worker run by Start calls items one by one in order of priority, items with the same priority "+order+".",
+			fmt.Sprintf("The queue holds %d calls, %s.", this.QueueSize, overflow))
+	default:
+		sentences = append(sentences, "Methods call items one by one in order of priority, items with the same priority "+order+".")
+	}
+
+	if this.Safe {
+		sentences = append(sentences, "It's safe for concurrent use.")
+	} else {
+		sentences = append(sentences, "It isn't safe for concurrent use.")
+	}
+
+	if this.Once {
+		sentences = append(sentences, "Every method calls items only once, items appended later are called with the arguments of that call right away.")
+	}
+
+	if this.Recover {
+		sentences = append(sentences, "Panics of items are recovered and reported to OnPanic.")
+	}
+
+	doc := wrap(strings.Join(sentences, " "), commentWidth)
+	if this.Doc != "" {
+		doc += "\n\n" + this.Doc
+	}
+
+	return doc
+}
+
+// MethodDoc returns the comment of the hook method describing the way it
+// aggregates results, the comment of the interface method follows it.
+func (this HookTemplate) MethodDoc(m Method) string {
+	doc := fmt.Sprintf("%s calls %s of every item", m.Name, m.Name)
+	if this.Dispatch == DispatchAsync {
+		doc = fmt.Sprintf("%s queues the call of %s of every item", m.Name, m.Name)
+	}
+
+	stop := ""
+	if this.Dispatch != DispatchParallel {
+		stop = " and doesn't call the rest"
+	}
+
+	switch m.Strategy {
+	case ResultsFirstError:
+		doc += ", it returns results of the first item returning an error" + stop
+	case ResultsAllErrors:
+		doc += ", it returns all errors of items and results of the last one"
+	case ResultsFirst:
+		doc += ", it returns the first non-zero results" + stop
+	case ResultsLast:
+		doc += ", it returns results of the last item"
+	case ResultsAll:
+		doc += ", it returns results of all items"
+	}
+
+	if m.Context != "" {
+		doc += ". Items aren't called after " + m.Context + " is done"
+	}
+
+	doc = wrap(doc+".", commentWidth)
+	if m.Doc != "" {
+		doc += "\n\n" + m.Doc
+	}
+
+	return doc
+}
+
+// RecorderDoc returns the comment of the recorder.
+func (this HookTemplate) RecorderDoc() string {
+	return wrap(fmt.Sprintf(
+		"%sRecorder implements %s by recording every call with its arguments, methods return zero values. "+
+			"It's safe for concurrent use, its zero value is ready to use.",
+		this.ItemField(), this.InterfaceName,
+	), commentWidth)
+}
+
+// CallDoc returns the comment of the call recorded by the recorder.
+func (this HookTemplate) CallDoc() string {
+	return wrap(fmt.Sprintf(
+		"%sCall is the call recorded by %sRecorder, only the field of the called method is set",
+		this.ItemField(), this.ItemField(),
+	), commentWidth)
+}
+
+// commentWidth is the max width of generated comments without markers
+const commentWidth = 76
+
+// wrap breaks text into lines not longer than width where it's possible
+func wrap(text string, width int) string {
+	lines := []string{}
+	line := ""
+
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+
+		if line != "" {
+			line += " "
+		}
+
+		line += word
+	}
+
+	return strings.Join(append(lines, line), "\n")
+}
+
+// comment turns text into the line comment
+func comment(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = "//"
+		} else {
+			lines[i] = "// " + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Uses reports whether any method aggregates results with strategy.
+func (this HookTemplate) Uses(strategy string) bool {
+	for _, m := range this.Methods {
+		if m.Strategy == strategy {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Method describes one method of the source interface, the hook
+// implements each of them by calling it on every registered item.
+type Method struct {
+	Name     string
+	DeclArgs []string
+	CallArgs []string
+	Params   []Param
+
+	DeclResults []string
+	Results     []Result
+	// Strategy of aggregation results, it's empty if method has no results
+	Strategy string
+
+	// Context is the name of the first argument if it is context.Context,
+	// the hook checks it before calling every item and stops if it's done
+	Context string
+
+	// Doc is the comment of the method of the source interface
+	Doc string
+}
+
+// Param describes one parameter of a method of the source interface.
+type Param struct {
+	Name string
+	// Type of variadic parameter is a slice
+	Type     string
+	Variadic bool
+}
+
+// Field returns the name of the field of the parameter in the recorded
+// call.
+func (this Param) Field() string {
+	r, size := utf8.DecodeRuneInString(this.Name)
+
+	return string(unicode.ToUpper(r)) + this.Name[size:]
+}
+
+// ResultNames returns names of the results of hook method.
+func (this Method) ResultNames() []string {
+	names := make([]string, 0, len(this.Results))
+	for _, r := range this.Results {
+		names = append(names, r.Name)
+	}
+
+	return names
+}
+
+// ItemDeclResults returns declarations of the results of item method like
+// "r0 int", they are the results of the source method unlike DeclResults
+// aggregated by the hook.
+func (this Method) ItemDeclResults() []string {
+	decls := make([]string, 0, len(this.Results))
+	for _, r := range this.Results {
+		decls = append(decls, r.Name+" "+r.Type)
+	}
+
+	return decls
+}
+
+// ResultVars returns names of the variables for the results of item method.
+func (this Method) ResultVars() []string {
+	vars := make([]string, 0, len(this.Results))
+	for _, r := range this.Results {
+		vars = append(vars, r.Var)
+	}
+
+	return vars
+}
+
+// NonZeros returns expressions which are true if results of item method
+// aren't zero values.
+func (this Method) NonZeros() []string {
+	exprs := make([]string, 0, len(this.Results))
+	for _, r := range this.Results {
+		exprs = append(exprs, r.NonZero)
+	}
+
+	return exprs
+}
+
+// ErrorResult returns the last result if it is error.
+func (this Method) ErrorResult() *Result {
+	if len(this.Results) == 0 || !this.Results[len(this.Results)-1].IsError {
+		return nil
+	}
+
+	return &this.Results[len(this.Results)-1]
 }
 
 func (this HookTemplate) String() string {
 	return hooktemplate
 }
 
+// Write writes the hook with the built-in template.
 func (this HookTemplate) Write(w io.Writer) error {
+	return this.Execute(w, hooktemplate)
+}
+
+// Execute executes the template text with the data of the hook, text may
+// use templates defined by the built-in one like "aggregate" and functions
+// of FuncMap.
+func (this HookTemplate) Execute(w io.Writer, text string) error {
 	t, err := template.
 		New("hooktemplate").
-		Funcs(template.FuncMap{
-			"join": strings.Join,
-		}).
+		Funcs(FuncMap()).
 		Parse(hooktemplate)
 	if err != nil {
 		return err
 	}
 
+	if text != hooktemplate {
+		if t, err = t.New("template").Parse(text); err != nil {
+			return err
+		}
+	}
+
 	return t.Execute(w, this)
 }
+
+// FuncMap returns functions available in templates:
+//
+//	join       strings.Join, like {{join .DeclArgs ", "}}
+//	comment    turns text into line comment, like {{comment .HookDoc}}
+//	background returns the expression for context of the type, it's
+//	           "context.Background()" for "context.Context"
+//	lower      strings.ToLower
+//	upper      strings.ToUpper
+//	quote      strconv.Quote
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"join":       strings.Join,
+		"comment":    comment,
+		"background": background,
+		"lower":      strings.ToLower,
+		"upper":      strings.ToUpper,
+		"quote":      strconv.Quote,
+	}
+}
@@ -15,20 +15,24 @@ func TestHookTemplate_Execute(t *testing.T) {
 		{
 			name: "Template{ Safe: false } doesn't use sync.Mutex",
 			this: HookTemplate{
-				Imports: []string{
-					"io",
+				Imports: []Import{
+					{Path: "io"},
 				},
 				InterfaceName: "Callback",
 				HookName:      "Hook",
 				PackageName:   "cbhook",
-				MethodName:    "Call",
-				MethodDeclArgs: []string{
-					"arg1 io.Writer",
-					"arg2 ...interface{}",
-				},
-				MethodCallArgs: []string{
-					"arg1",
-					"arg2...",
+				Methods: []Method{
+					{
+						Name: "Call",
+						DeclArgs: []string{
+							"arg1 io.Writer",
+							"arg2 ...interface{}",
+						},
+						CallArgs: []string{
+							"arg1",
+							"arg2...",
+						},
+					},
 				},
 				Safe: false,
 			},
@@ -42,34 +46,114 @@ import (
     "io"
 )
 
+// Hook implements Callback by calling every registered item. Methods call
+// items one by one in order of priority, items with the same priority in order
+// of registration. It isn't safe for concurrent use.
 type Hook struct {
-    list []*hookedItem
+    list []*hookedHook
 }
 
-type hooked struct {
+type hookedHook struct {
     Callback
+    priority int
+    once     bool
+    fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook) Append(item Callback) HookCancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in order of
+// registration
+func (this *Hook) AppendWithPriority(item Callback, priority int) HookCancel {
+    return this.insert(item, priority, false, false)
+}
+
+// Prepend registers item to be called before all registered items
+func (this *Hook) Prepend(item Callback) HookCancel {
+    return this.insert(item, 0, true, false)
 }
 
-type Cancel = func()
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook) AppendOnce(item Callback) HookCancel {
+    return this.insert(item, 0, false, true)
+}
+
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook) insert(item Callback, priority int, first, once bool) HookCancel {
+    list := this.list
+
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority >= priority {
+            i++
+        }
+    }
 
-func (this *Hook) Append(item Callback) Cancel {
-    hooked := &hooked{item}
-    this.list = append(this.list, hooked)
+    entry := &hookedHook{item, priority, once, 0}
 
-    return func() { this.remove(hooked) }
+    rest := make([]*hookedHook, 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    this.list = rest
+
+    return func() { this.remove(entry) }
 }
 
-func (this *Hook) remove(hooked *hooked) {
-    for i := range this.list {
-        if this.list[i] == hooked {
-            this.list = append(this.list[:i], this.list[i+1:]...)
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook) remove(entry *hookedHook) {
+    list := this.list
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hookedHook, 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            this.list = rest
             break
         }
     }
 }
 
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook) claim(entry *hookedHook) bool {
+    if !entry.once {
+        return true
+    }
+
+    if entry.fired != 0 {
+        return false
+    }
+
+    entry.fired = 1
+
+    this.remove(entry)
+
+    return true
+}
+
+// Call calls Call of every item.
 func (this *Hook) Call(arg1 io.Writer, arg2 ...interface{}) {
     for _, hooked := range this.list {
+        if !this.claim(hooked) {
+            continue
+        }
+
         hooked.Call(arg1, arg2...)
     }
 }
@@ -79,20 +163,24 @@ func (this *Hook) Call(arg1 io.Writer, arg2 ...interface{}) {
 		{
 			name: "Template{ Safe: true } uses sync.Mutex",
 			this: HookTemplate{
-				Imports: []string{
-					"io",
+				Imports: []Import{
+					{Path: "io"},
 				},
 				InterfaceName: "Callback",
 				HookName:      "Hook",
 				PackageName:   "cbhook",
-				MethodName:    "Call",
-				MethodDeclArgs: []string{
-					"arg1 io.Writer",
-					"arg2 ...interface{}",
-				},
-				MethodCallArgs: []string{
-					"arg1",
-					"arg2...",
+				Methods: []Method{
+					{
+						Name: "Call",
+						DeclArgs: []string{
+							"arg1 io.Writer",
+							"arg2 ...interface{}",
+						},
+						CallArgs: []string{
+							"arg1",
+							"arg2...",
+						},
+					},
 				},
 				Safe: true,
 			},
@@ -105,62 +193,3553 @@ package cbhook
 import (
     "io"
     "sync"
+    "sync/atomic"
 )
 
+// Hook implements Callback by calling every registered item. Methods call
+// items one by one in order of priority, items with the same priority in order
+// of registration. It's safe for concurrent use.
 type Hook struct {
-    list []*hookedItem
+    list []*hookedHook
     m sync.Mutex
 }
 
-type hooked struct {
+type hookedHook struct {
     Callback
+    priority int
+    once     bool
+    fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook) Append(item Callback) HookCancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in order of
+// registration
+func (this *Hook) AppendWithPriority(item Callback, priority int) HookCancel {
+    return this.insert(item, priority, false, false)
 }
 
-type Cancel = func()
+// Prepend registers item to be called before all registered items
+func (this *Hook) Prepend(item Callback) HookCancel {
+    return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook) AppendOnce(item Callback) HookCancel {
+    return this.insert(item, 0, false, true)
+}
 
-func (this *Hook) Append(item Callback) Cancel {
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook) insert(item Callback, priority int, first, once bool) HookCancel {
     this.m.Lock()
     defer this.m.Unlock()
 
-    hooked := &hooked{item}
-    this.list = append(this.list, hooked)
+    list := this.list
+
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority >= priority {
+            i++
+        }
+    }
+
+    entry := &hookedHook{item, priority, once, 0}
 
-    return func() { this.remove(hooked) }
+    rest := make([]*hookedHook, 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    this.list = rest
+
+    return func() { this.remove(entry) }
 }
 
-func (this *Hook) remove(hooked *hooked) {
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook) remove(entry *hookedHook) {
     this.m.Lock()
     defer this.m.Unlock()
 
-    for i := range this.list {
-        if this.list[i] == hooked {
-            this.list = append(this.list[:i], this.list[i+1:]...)
+    list := this.list
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hookedHook, 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            this.list = rest
             break
         }
     }
 }
 
-func (this *Hook) Call(arg1 io.Writer, arg2 ...interface{}) {
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook) claim(entry *hookedHook) bool {
+    if !entry.once {
+        return true
+    }
+
+    if !atomic.CompareAndSwapUint32(&entry.fired, 0, 1) {
+        return false
+    }
+
+    this.remove(entry)
+
+    return true
+}
+
+// items returns the list of hooked items which is never changed in place
+func (this *Hook) items() []*hookedHook {
     this.m.Lock()
     defer this.m.Unlock()
 
-    for _, hooked := range this.list {
+    return this.list
+}
+
+// Call calls Call of every item.
+func (this *Hook) Call(arg1 io.Writer, arg2 ...interface{}) {
+    list := this.items()
+
+    for _, hooked := range list {
+        if !this.claim(hooked) {
+            continue
+        }
+
         hooked.Call(arg1, arg2...)
     }
 }
 `,
 			wantErr: false,
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			w := &bytes.Buffer{}
-			if err := tt.this.Write(w); (err != nil) != tt.wantErr {
-				t.Errorf("Template.Write() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if gotW := w.String(); gotW != tt.wantW {
-				t.Errorf("Template.Write() = %v, want %v", gotW, tt.wantW)
+		{
+			name: "Template with many methods fans out each of them",
+			this: HookTemplate{
+				InterfaceName: "Lifecycle",
+				HookName:      "Hook",
+				PackageName:   "lchook",
+				Methods: []Method{
+					{
+						Name:     "Start",
+						DeclArgs: []string{"name string"},
+						CallArgs: []string{"name"},
+					},
+					{
+						Name:     "Stop",
+						DeclArgs: []string{},
+						CallArgs: []string{},
+					},
+				},
+				Safe: false,
+			},
+			// Use only spaces for indentation
+			wantW: `// Code generated by hookgen; DO NOT EDIT.
+// github.com/axard/things/cmd/hookgen
+
+package lchook
+
+import (
+)
+
+// Hook implements Lifecycle by calling every registered item. Methods call
+// items one by one in order of priority, items with the same priority in order
+// of registration. It isn't safe for concurrent use.
+type Hook struct {
+    list []*hookedHook
+}
+
+type hookedHook struct {
+    Lifecycle
+    priority int
+    once     bool
+    fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook) Append(item Lifecycle) HookCancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in order of
+// registration
+func (this *Hook) AppendWithPriority(item Lifecycle, priority int) HookCancel {
+    return this.insert(item, priority, false, false)
+}
+
+// Prepend registers item to be called before all registered items
+func (this *Hook) Prepend(item Lifecycle) HookCancel {
+    return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook) AppendOnce(item Lifecycle) HookCancel {
+    return this.insert(item, 0, false, true)
+}
+
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook) insert(item Lifecycle, priority int, first, once bool) HookCancel {
+    list := this.list
+
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority >= priority {
+            i++
+        }
+    }
+
+    entry := &hookedHook{item, priority, once, 0}
+
+    rest := make([]*hookedHook, 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    this.list = rest
+
+    return func() { this.remove(entry) }
+}
+
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook) remove(entry *hookedHook) {
+    list := this.list
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hookedHook, 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            this.list = rest
+            break
+        }
+    }
+}
+
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook) claim(entry *hookedHook) bool {
+    if !entry.once {
+        return true
+    }
+
+    if entry.fired != 0 {
+        return false
+    }
+
+    entry.fired = 1
+
+    this.remove(entry)
+
+    return true
+}
+
+// Start calls Start of every item.
+func (this *Hook) Start(name string) {
+    for _, hooked := range this.list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        hooked.Start(name)
+    }
+}
+
+// Stop calls Stop of every item.
+func (this *Hook) Stop() {
+    for _, hooked := range this.list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        hooked.Stop()
+    }
+}
+`,
+			wantErr: false,
+		},
+		{
+			name: "Template with results aggregates them by strategy",
+			this: HookTemplate{
+				InterfaceName: "Closer",
+				HookName:      "Hook",
+				PackageName:   "clhook",
+				Methods: []Method{
+					{
+						Name:        "Close",
+						DeclArgs:    []string{},
+						CallArgs:    []string{},
+						DeclResults: []string{"r0 error"},
+						Results: []Result{
+							{Name: "r0", Var: "v0", Type: "error", IsError: true},
+						},
+						Strategy: ResultsFirstError,
+					},
+					{
+						Name:        "Flush",
+						DeclArgs:    []string{},
+						CallArgs:    []string{},
+						DeclResults: []string{"r0 int", "r1 error"},
+						Results: []Result{
+							{Name: "r0", Var: "v0", Type: "int"},
+							{Name: "r1", Var: "v1", Type: "error", IsError: true},
+						},
+						Strategy: ResultsAllErrors,
+					},
+				},
+				Safe: false,
+			},
+			// Use only spaces for indentation
+			wantW: `// Code generated by hookgen; DO NOT EDIT.
+// github.com/axard/things/cmd/hookgen
+
+package clhook
+
+import (
+)
+
+// Hook implements Closer by calling every registered item. Methods call items
+// one by one in order of priority, items with the same priority in order of
+// registration. It isn't safe for concurrent use.
+type Hook struct {
+    list []*hookedHook
+}
+
+type hookedHook struct {
+    Closer
+    priority int
+    once     bool
+    fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook) Append(item Closer) HookCancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in order of
+// registration
+func (this *Hook) AppendWithPriority(item Closer, priority int) HookCancel {
+    return this.insert(item, priority, false, false)
+}
+
+// Prepend registers item to be called before all registered items
+func (this *Hook) Prepend(item Closer) HookCancel {
+    return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook) AppendOnce(item Closer) HookCancel {
+    return this.insert(item, 0, false, true)
+}
+
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook) insert(item Closer, priority int, first, once bool) HookCancel {
+    list := this.list
+
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority >= priority {
+            i++
+        }
+    }
+
+    entry := &hookedHook{item, priority, once, 0}
+
+    rest := make([]*hookedHook, 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    this.list = rest
+
+    return func() { this.remove(entry) }
+}
+
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook) remove(entry *hookedHook) {
+    list := this.list
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hookedHook, 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            this.list = rest
+            break
+        }
+    }
+}
+
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook) claim(entry *hookedHook) bool {
+    if !entry.once {
+        return true
+    }
+
+    if entry.fired != 0 {
+        return false
+    }
+
+    entry.fired = 1
+
+    this.remove(entry)
+
+    return true
+}
+
+type HookErrors []error
+
+func (this HookErrors) Error() string {
+    msg := ""
+    for i, err := range this {
+        if i > 0 {
+            msg += "; "
+        }
+        msg += err.Error()
+    }
+
+    return msg
+}
+
+func (this HookErrors) Unwrap() []error {
+    return this
+}
+
+func (this HookErrors) err() error {
+    if len(this) == 0 {
+        return nil
+    }
+
+    return this
+}
+
+// Close calls Close of every item, it returns results of the first item
+// returning an error and doesn't call the rest.
+func (this *Hook) Close() (r0 error) {
+    for _, hooked := range this.list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        v0 := hooked.Close()
+        r0 = v0
+        if v0 != nil {
+            return
+        }
+    }
+
+    return
+}
+
+// Flush calls Flush of every item, it returns all errors of items and results
+// of the last one.
+func (this *Hook) Flush() (r0 int, r1 error) {
+    var errs HookErrors
+
+    for _, hooked := range this.list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        v0, v1 := hooked.Flush()
+        r0 = v0
+        if v1 != nil {
+            errs = append(errs, v1)
+        }
+    }
+
+    r1 = errs.err()
+
+    return
+}
+`,
+			wantErr: false,
+		},
+		{
+			name: "Template with context stops when context is done",
+			this: HookTemplate{
+				Imports: []Import{
+					{Path: "context"},
+				},
+				InterfaceName: "Stopper",
+				HookName:      "Hook",
+				PackageName:   "sthook",
+				Methods: []Method{
+					{
+						Name:        "Notify",
+						DeclArgs:    []string{"ctx context.Context"},
+						CallArgs:    []string{"ctx"},
+						DeclResults: []string{},
+						Results:     []Result{},
+						Context:     "ctx",
+					},
+					{
+						Name:        "Stop",
+						DeclArgs:    []string{"ctx context.Context"},
+						CallArgs:    []string{"ctx"},
+						DeclResults: []string{"r0 error"},
+						Results: []Result{
+							{Name: "r0", Var: "v0", Type: "error", IsError: true},
+						},
+						Strategy: ResultsFirstError,
+						Context:  "ctx",
+					},
+				},
+				Safe: false,
+			},
+			// Use only spaces for indentation
+			wantW: `// Code generated by hookgen; DO NOT EDIT.
+// github.com/axard/things/cmd/hookgen
+
+package sthook
+
+import (
+    "context"
+)
+
+// Hook implements Stopper by calling every registered item. Methods call items
+// one by one in order of priority, items with the same priority in order of
+// registration. It isn't safe for concurrent use.
+type Hook struct {
+    list []*hookedHook
+}
+
+type hookedHook struct {
+    Stopper
+    priority int
+    once     bool
+    fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook) Append(item Stopper) HookCancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in order of
+// registration
+func (this *Hook) AppendWithPriority(item Stopper, priority int) HookCancel {
+    return this.insert(item, priority, false, false)
+}
+
+// Prepend registers item to be called before all registered items
+func (this *Hook) Prepend(item Stopper) HookCancel {
+    return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook) AppendOnce(item Stopper) HookCancel {
+    return this.insert(item, 0, false, true)
+}
+
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook) insert(item Stopper, priority int, first, once bool) HookCancel {
+    list := this.list
+
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority >= priority {
+            i++
+        }
+    }
+
+    entry := &hookedHook{item, priority, once, 0}
+
+    rest := make([]*hookedHook, 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    this.list = rest
+
+    return func() { this.remove(entry) }
+}
+
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook) remove(entry *hookedHook) {
+    list := this.list
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hookedHook, 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            this.list = rest
+            break
+        }
+    }
+}
+
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook) claim(entry *hookedHook) bool {
+    if !entry.once {
+        return true
+    }
+
+    if entry.fired != 0 {
+        return false
+    }
+
+    entry.fired = 1
+
+    this.remove(entry)
+
+    return true
+}
+
+// Notify calls Notify of every item. Items aren't called after ctx is done.
+func (this *Hook) Notify(ctx context.Context) {
+    for _, hooked := range this.list {
+        if err := ctx.Err(); err != nil {
+            return
+        }
+
+        if !this.claim(hooked) {
+            continue
+        }
+
+        hooked.Notify(ctx)
+    }
+}
+
+// Stop calls Stop of every item, it returns results of the first item
+// returning an error and doesn't call the rest. Items aren't called after ctx
+// is done.
+func (this *Hook) Stop(ctx context.Context) (r0 error) {
+    for _, hooked := range this.list {
+        if err := ctx.Err(); err != nil {
+            r0 = err
+            return
+        }
+
+        if !this.claim(hooked) {
+            continue
+        }
+
+        v0 := hooked.Stop(ctx)
+        r0 = v0
+        if v0 != nil {
+            return
+        }
+    }
+
+    return
+}
+`,
+			wantErr: false,
+		},
+		{
+			name: "Template{ Dispatch: parallel } calls items in goroutines",
+			this: HookTemplate{
+				Imports: []Import{
+					{Path: "context"},
+				},
+				InterfaceName: "Stopper",
+				HookName:      "Hook",
+				PackageName:   "sthook",
+				Methods: []Method{
+					{
+						Name:        "Notify",
+						DeclArgs:    []string{"name string"},
+						CallArgs:    []string{"name"},
+						DeclResults: []string{},
+						Results:     []Result{},
+					},
+					{
+						Name:        "Stop",
+						DeclArgs:    []string{"ctx context.Context"},
+						CallArgs:    []string{"ctx"},
+						DeclResults: []string{"r0 error"},
+						Results: []Result{
+							{Name: "r0", Var: "v0", Type: "error", IsError: true},
+						},
+						Strategy: ResultsAllErrors,
+						Context:  "ctx",
+					},
+				},
+				Safe:        false,
+				Dispatch:    DispatchParallel,
+				Concurrency: 2,
+			},
+			// Use only spaces for indentation
+			wantW: `// Code generated by hookgen; DO NOT EDIT.
+// github.com/axard/things/cmd/hookgen
+
+package sthook
+
+import (
+    "context"
+    "sync"
+)
+
+// Hook implements Stopper by calling every registered item. Methods call items
+// concurrently, at most 2 at once and wait for them. It isn't safe for
+// concurrent use.
+type Hook struct {
+    list []*hookedHook
+}
+
+type hookedHook struct {
+    Stopper
+    priority int
+    once     bool
+    fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook) Append(item Stopper) HookCancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in order of
+// registration
+func (this *Hook) AppendWithPriority(item Stopper, priority int) HookCancel {
+    return this.insert(item, priority, false, false)
+}
+
+// Prepend registers item to be called before all registered items
+func (this *Hook) Prepend(item Stopper) HookCancel {
+    return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook) AppendOnce(item Stopper) HookCancel {
+    return this.insert(item, 0, false, true)
+}
+
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook) insert(item Stopper, priority int, first, once bool) HookCancel {
+    list := this.list
+
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority >= priority {
+            i++
+        }
+    }
+
+    entry := &hookedHook{item, priority, once, 0}
+
+    rest := make([]*hookedHook, 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    this.list = rest
+
+    return func() { this.remove(entry) }
+}
+
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook) remove(entry *hookedHook) {
+    list := this.list
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hookedHook, 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            this.list = rest
+            break
+        }
+    }
+}
+
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook) claim(entry *hookedHook) bool {
+    if !entry.once {
+        return true
+    }
+
+    if entry.fired != 0 {
+        return false
+    }
+
+    entry.fired = 1
+
+    this.remove(entry)
+
+    return true
+}
+
+// claimed returns the items which can be called
+func (this *Hook) claimed(list []*hookedHook) []*hookedHook {
+    rest := make([]*hookedHook, 0, len(list))
+    for _, entry := range list {
+        if this.claim(entry) {
+            rest = append(rest, entry)
+        }
+    }
+
+    return rest
+}
+
+type HookErrors []error
+
+func (this HookErrors) Error() string {
+    msg := ""
+    for i, err := range this {
+        if i > 0 {
+            msg += "; "
+        }
+        msg += err.Error()
+    }
+
+    return msg
+}
+
+func (this HookErrors) Unwrap() []error {
+    return this
+}
+
+func (this HookErrors) err() error {
+    if len(this) == 0 {
+        return nil
+    }
+
+    return this
+}
+
+// Notify calls Notify of every item.
+func (this *Hook) Notify(name string) {
+    list := this.claimed(this.list)
+
+    wg := sync.WaitGroup{}
+    sem := make(chan struct{}, 2)
+
+    for _, hooked := range list {
+        sem <- struct{}{}
+        hooked := hooked
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            defer func() { <-sem }()
+
+            hooked.Notify(name)
+        }()
+    }
+
+    wg.Wait()
+}
+
+// Stop calls Stop of every item, it returns all errors of items and results of
+// the last one. Items aren't called after ctx is done.
+func (this *Hook) Stop(ctx context.Context) (r0 error) {
+    var errs HookErrors
+
+    list := this.claimed(this.list)
+
+    results := make([]struct {
+        v0 error
+    }, len(list))
+    wg := sync.WaitGroup{}
+    sem := make(chan struct{}, 2)
+    started := 0
+    var ctxErr error
+
+    for i, hooked := range list {
+        if ctxErr = ctx.Err(); ctxErr != nil {
+            break
+        }
+
+        select {
+        case sem <- struct{}{}:
+        case <-ctx.Done():
+            ctxErr = ctx.Err()
+        }
+
+        if ctxErr != nil {
+            break
+        }
+
+        started++
+        i, hooked := i, hooked
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            defer func() { <-sem }()
+
+            results[i].v0 = hooked.Stop(ctx)
+        }()
+    }
+
+    wg.Wait()
+
+    for _, result := range results[:started] {
+        v0 := result.v0
+        if v0 != nil {
+            errs = append(errs, v0)
+        }
+    }
+
+    if ctxErr != nil {
+        errs = append(errs, ctxErr)
+    }
+
+    r0 = errs.err()
+
+    return
+}
+`,
+			wantErr: false,
+		},
+		{
+			name: "Template{ Dispatch: async } queues calls",
+			this: HookTemplate{
+				Imports: []Import{
+					{Path: "io"},
+				},
+				InterfaceName: "Logger",
+				HookName:      "Hook",
+				PackageName:   "loghook",
+				Methods: []Method{
+					{
+						Name:        "Log",
+						DeclArgs:    []string{"w io.Writer", "args ...interface{}"},
+						CallArgs:    []string{"w", "args..."},
+						Params:      []Param{{Name: "w", Type: "io.Writer"}, {Name: "args", Type: "[]interface{}", Variadic: true}},
+						DeclResults: []string{},
+						Results:     []Result{},
+					},
+					{
+						Name:        "Sync",
+						DeclArgs:    []string{},
+						CallArgs:    []string{},
+						Params:      []Param{},
+						DeclResults: []string{},
+						Results:     []Result{},
+					},
+				},
+				Safe:      false,
+				Dispatch:  DispatchAsync,
+				QueueSize: 16,
+				Overflow:  OverflowDropOldest,
+			},
+			// Use only spaces for indentation
+			wantW: `// Code generated by hookgen; DO NOT EDIT.
+// github.com/axard/things/cmd/hookgen
+
+package loghook
+
+import (
+    "io"
+    "sync"
+)
+
+// Hook implements Logger by calling every registered item. Methods queue calls
+// and return at once, the worker run by Start calls items one by one in order
+// of priority, items with the same priority in order of registration. The
+// queue holds 16 calls, the oldest queued call is dropped while the queue is
+// full. It isn't safe for concurrent use.
+type Hook struct {
+    list []*hookedHook
+
+    queue   chan eventHook
+    queueM  sync.RWMutex
+    closing chan struct{}
+    done    chan struct{}
+}
+
+type hookedHook struct {
+    Logger
+    priority int
+    once     bool
+    fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook) Append(item Logger) HookCancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in order of
+// registration
+func (this *Hook) AppendWithPriority(item Logger, priority int) HookCancel {
+    return this.insert(item, priority, false, false)
+}
+
+// Prepend registers item to be called before all registered items
+func (this *Hook) Prepend(item Logger) HookCancel {
+    return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook) AppendOnce(item Logger) HookCancel {
+    return this.insert(item, 0, false, true)
+}
+
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook) insert(item Logger, priority int, first, once bool) HookCancel {
+    list := this.list
+
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority >= priority {
+            i++
+        }
+    }
+
+    entry := &hookedHook{item, priority, once, 0}
+
+    rest := make([]*hookedHook, 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    this.list = rest
+
+    return func() { this.remove(entry) }
+}
+
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook) remove(entry *hookedHook) {
+    list := this.list
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hookedHook, 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            this.list = rest
+            break
+        }
+    }
+}
+
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook) claim(entry *hookedHook) bool {
+    if !entry.once {
+        return true
+    }
+
+    if entry.fired != 0 {
+        return false
+    }
+
+    entry.fired = 1
+
+    this.remove(entry)
+
+    return true
+}
+
+type eventHook interface {
+    dispatch(hook *Hook)
+}
+
+// Start runs the worker calling hooked items, calls made before Start or
+// after Close are dropped.
+func (this *Hook) Start() {
+    this.queueM.Lock()
+    defer this.queueM.Unlock()
+
+    if this.queue != nil {
+        return
+    }
+
+    queue := make(chan eventHook, 16)
+    closing := make(chan struct{})
+    done := make(chan struct{})
+
+    go func() {
+        defer close(done)
+
+        for {
+            select {
+            case event := <-queue:
+                event.dispatch(this)
+            case <-closing:
+                for {
+                    select {
+                    case event := <-queue:
+                        event.dispatch(this)
+                    default:
+                        return
+                    }
+                }
+            }
+        }
+    }()
+
+    this.queue, this.closing, this.done = queue, closing, done
+}
+
+// Close stops accepting calls and waits until the queued ones are done.
+// Items must not call it, it would wait for the item calling it forever,
+// they may call it in a new goroutine like "go hook.Close()".
+func (this *Hook) Close() {
+    this.queueM.Lock()
+    closing, done := this.closing, this.done
+    this.queue, this.closing, this.done = nil, nil, nil
+    this.queueM.Unlock()
+
+    if closing == nil {
+        return
+    }
+
+    close(closing)
+    <-done
+}
+
+// enqueue doesn't hold the lock while it sends the call, the queue is never
+// closed and the call blocked on it gives up when the hook is closed
+func (this *Hook) enqueue(event eventHook) {
+    this.queueM.RLock()
+    queue := this.queue
+    this.queueM.RUnlock()
+
+    if queue == nil {
+        return
+    }
+
+    for {
+        select {
+        case queue <- event:
+            return
+        default:
+        }
+
+        select {
+        case <-queue:
+        default:
+        }
+    }
+}
+
+type eventHookLog struct {
+    w io.Writer
+    args []interface{}
+}
+
+func (this eventHookLog) dispatch(hook *Hook) {
+    hook.fireLog(this.w, this.args...)
+}
+
+// Log queues the call of Log of every item.
+func (this *Hook) Log(w io.Writer, args ...interface{}) {
+    this.enqueue(eventHookLog{w, args})
+}
+
+func (this *Hook) fireLog(w io.Writer, args ...interface{}) {
+    for _, hooked := range this.list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        hooked.Log(w, args...)
+    }
+}
+
+type eventHookSync struct {}
+
+func (this eventHookSync) dispatch(hook *Hook) {
+    hook.fireSync()
+}
+
+// Sync queues the call of Sync of every item.
+func (this *Hook) Sync() {
+    this.enqueue(eventHookSync{})
+}
+
+func (this *Hook) fireSync() {
+    for _, hooked := range this.list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        hooked.Sync()
+    }
+}
+`,
+			wantErr: false,
+		},
+		{
+			name: "Template{ Safe: true, Sync: atomic } keeps list in atomic.Value",
+			this: HookTemplate{
+				InterfaceName: "Callback",
+				HookName:      "Hook",
+				PackageName:   "cbhook",
+				Methods: []Method{
+					{
+						Name:        "Call",
+						DeclArgs:    []string{"n int"},
+						CallArgs:    []string{"n"},
+						Params:      []Param{{Name: "n", Type: "int"}},
+						DeclResults: []string{},
+						Results:     []Result{},
+					},
+				},
+				Safe: true,
+				Sync: SyncAtomic,
+			},
+			// Use only spaces for indentation
+			wantW: `// Code generated by hookgen; DO NOT EDIT.
+// github.com/axard/things/cmd/hookgen
+
+package cbhook
+
+import (
+    "sync"
+    "sync/atomic"
+)
+
+// Hook implements Callback by calling every registered item. Methods call
+// items one by one in order of priority, items with the same priority in order
+// of registration. It's safe for concurrent use.
+type Hook struct {
+    list atomic.Value
+    m sync.Mutex
+}
+
+type hookedHook struct {
+    Callback
+    priority int
+    once     bool
+    fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook) Append(item Callback) HookCancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in order of
+// registration
+func (this *Hook) AppendWithPriority(item Callback, priority int) HookCancel {
+    return this.insert(item, priority, false, false)
+}
+
+// Prepend registers item to be called before all registered items
+func (this *Hook) Prepend(item Callback) HookCancel {
+    return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook) AppendOnce(item Callback) HookCancel {
+    return this.insert(item, 0, false, true)
+}
+
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook) insert(item Callback, priority int, first, once bool) HookCancel {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    list, _ := this.list.Load().([]*hookedHook)
+
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority >= priority {
+            i++
+        }
+    }
+
+    entry := &hookedHook{item, priority, once, 0}
+
+    rest := make([]*hookedHook, 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    this.list.Store(rest)
+
+    return func() { this.remove(entry) }
+}
+
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook) remove(entry *hookedHook) {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    list, _ := this.list.Load().([]*hookedHook)
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hookedHook, 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            this.list.Store(rest)
+            break
+        }
+    }
+}
+
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook) claim(entry *hookedHook) bool {
+    if !entry.once {
+        return true
+    }
+
+    if !atomic.CompareAndSwapUint32(&entry.fired, 0, 1) {
+        return false
+    }
+
+    this.remove(entry)
+
+    return true
+}
+
+// items returns the list of hooked items which is never changed in place
+func (this *Hook) items() []*hookedHook {
+    list, _ := this.list.Load().([]*hookedHook)
+
+    return list
+}
+
+// Call calls Call of every item.
+func (this *Hook) Call(n int) {
+    list := this.items()
+
+    for _, hooked := range list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        hooked.Call(n)
+    }
+}
+`,
+			wantErr: false,
+		},
+		{
+			name: "Template{ TypeParams: [T any] } declares generic hook",
+			this: HookTemplate{
+				InterfaceName: "Listener[T]",
+				HookName:      "Hook",
+				PackageName:   "events",
+				Methods: []Method{
+					{
+						Name:        "On",
+						DeclArgs:    []string{"event T"},
+						CallArgs:    []string{"event"},
+						Params:      []Param{{Name: "event", Type: "T"}},
+						DeclResults: []string{},
+						Results:     []Result{},
+					},
+				},
+				TypeParams: "[T any]",
+				TypeArgs:   "[T]",
+				Dispatch:   DispatchAsync,
+				QueueSize:  8,
+				Overflow:   OverflowBlock,
+			},
+			// Use only spaces for indentation
+			wantW: `// Code generated by hookgen; DO NOT EDIT.
+// github.com/axard/things/cmd/hookgen
+
+package events
+
+import (
+    "sync"
+)
+
+// Hook implements Listener[T] by calling every registered item. Methods queue
+// calls and return at once, the worker run by Start calls items one by one in
+// order of priority, items with the same priority in order of registration.
+// The queue holds 8 calls, a call blocks while the queue is full until Close.
+// It isn't safe for concurrent use.
+type Hook[T any] struct {
+    list []*hookedHook[T]
+
+    queue   chan eventHook[T]
+    queueM  sync.RWMutex
+    closing chan struct{}
+    done    chan struct{}
+}
+
+type hookedHook[T any] struct {
+    Listener[T]
+    priority int
+    once     bool
+    fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook[T]) Append(item Listener[T]) HookCancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in order of
+// registration
+func (this *Hook[T]) AppendWithPriority(item Listener[T], priority int) HookCancel {
+    return this.insert(item, priority, false, false)
+}
+
+// Prepend registers item to be called before all registered items
+func (this *Hook[T]) Prepend(item Listener[T]) HookCancel {
+    return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook[T]) AppendOnce(item Listener[T]) HookCancel {
+    return this.insert(item, 0, false, true)
+}
+
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook[T]) insert(item Listener[T], priority int, first, once bool) HookCancel {
+    list := this.list
+
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority >= priority {
+            i++
+        }
+    }
+
+    entry := &hookedHook[T]{item, priority, once, 0}
+
+    rest := make([]*hookedHook[T], 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    this.list = rest
+
+    return func() { this.remove(entry) }
+}
+
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook[T]) remove(entry *hookedHook[T]) {
+    list := this.list
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hookedHook[T], 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            this.list = rest
+            break
+        }
+    }
+}
+
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook[T]) claim(entry *hookedHook[T]) bool {
+    if !entry.once {
+        return true
+    }
+
+    if entry.fired != 0 {
+        return false
+    }
+
+    entry.fired = 1
+
+    this.remove(entry)
+
+    return true
+}
+
+type eventHook[T any] interface {
+    dispatch(hook *Hook[T])
+}
+
+// Start runs the worker calling hooked items, calls made before Start or
+// after Close are dropped.
+func (this *Hook[T]) Start() {
+    this.queueM.Lock()
+    defer this.queueM.Unlock()
+
+    if this.queue != nil {
+        return
+    }
+
+    queue := make(chan eventHook[T], 8)
+    closing := make(chan struct{})
+    done := make(chan struct{})
+
+    go func() {
+        defer close(done)
+
+        for {
+            select {
+            case event := <-queue:
+                event.dispatch(this)
+            case <-closing:
+                for {
+                    select {
+                    case event := <-queue:
+                        event.dispatch(this)
+                    default:
+                        return
+                    }
+                }
+            }
+        }
+    }()
+
+    this.queue, this.closing, this.done = queue, closing, done
+}
+
+// Close stops accepting calls and waits until the queued ones are done.
+// Items must not call it, it would wait for the item calling it forever,
+// they may call it in a new goroutine like "go hook.Close()".
+func (this *Hook[T]) Close() {
+    this.queueM.Lock()
+    closing, done := this.closing, this.done
+    this.queue, this.closing, this.done = nil, nil, nil
+    this.queueM.Unlock()
+
+    if closing == nil {
+        return
+    }
+
+    close(closing)
+    <-done
+}
+
+// enqueue doesn't hold the lock while it sends the call, the queue is never
+// closed and the call blocked on it gives up when the hook is closed
+func (this *Hook[T]) enqueue(event eventHook[T]) {
+    this.queueM.RLock()
+    queue, closing := this.queue, this.closing
+    this.queueM.RUnlock()
+
+    if queue == nil {
+        return
+    }
+
+    select {
+    case queue <- event:
+    case <-closing:
+    }
+}
+
+type eventHookOn[T any] struct {
+    event T
+}
+
+func (this eventHookOn[T]) dispatch(hook *Hook[T]) {
+    hook.fireOn(this.event)
+}
+
+// On queues the call of On of every item.
+func (this *Hook[T]) On(event T) {
+    this.enqueue(eventHookOn[T]{event})
+}
+
+func (this *Hook[T]) fireOn(event T) {
+    for _, hooked := range this.list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        hooked.On(event)
+    }
+}
+`,
+			wantErr: false,
+		},
+		{
+			name: "Template{ Order: lifo } calls items in reverse order of registration",
+			this: HookTemplate{
+				InterfaceName: "Closer",
+				HookName:      "Hook",
+				PackageName:   "teardown",
+				Methods: []Method{
+					{
+						Name:        "Close",
+						DeclArgs:    []string{},
+						CallArgs:    []string{},
+						Params:      []Param{},
+						DeclResults: []string{},
+						Results:     []Result{},
+					},
+				},
+				Safe:  true,
+				Sync:  SyncAtomic,
+				Order: OrderLIFO,
+			},
+			// Use only spaces for indentation
+			wantW: `// Code generated by hookgen; DO NOT EDIT.
+// github.com/axard/things/cmd/hookgen
+
+package teardown
+
+import (
+    "sync"
+    "sync/atomic"
+)
+
+// Hook implements Closer by calling every registered item. Methods call items
+// one by one in order of priority, items with the same priority in reverse
+// order of registration. It's safe for concurrent use.
+type Hook struct {
+    list atomic.Value
+    m sync.Mutex
+}
+
+type hookedHook struct {
+    Closer
+    priority int
+    once     bool
+    fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook) Append(item Closer) HookCancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in reverse order of
+// registration
+func (this *Hook) AppendWithPriority(item Closer, priority int) HookCancel {
+    return this.insert(item, priority, false, false)
+}
+
+// Prepend registers item to be called before all registered items
+func (this *Hook) Prepend(item Closer) HookCancel {
+    return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook) AppendOnce(item Closer) HookCancel {
+    return this.insert(item, 0, false, true)
+}
+
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook) insert(item Closer, priority int, first, once bool) HookCancel {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    list, _ := this.list.Load().([]*hookedHook)
+
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority > priority {
+            i++
+        }
+    }
+
+    entry := &hookedHook{item, priority, once, 0}
+
+    rest := make([]*hookedHook, 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    this.list.Store(rest)
+
+    return func() { this.remove(entry) }
+}
+
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook) remove(entry *hookedHook) {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    list, _ := this.list.Load().([]*hookedHook)
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hookedHook, 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            this.list.Store(rest)
+            break
+        }
+    }
+}
+
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook) claim(entry *hookedHook) bool {
+    if !entry.once {
+        return true
+    }
+
+    if !atomic.CompareAndSwapUint32(&entry.fired, 0, 1) {
+        return false
+    }
+
+    this.remove(entry)
+
+    return true
+}
+
+// items returns the list of hooked items which is never changed in place
+func (this *Hook) items() []*hookedHook {
+    list, _ := this.list.Load().([]*hookedHook)
+
+    return list
+}
+
+// Close calls Close of every item.
+func (this *Hook) Close() {
+    list := this.items()
+
+    for _, hooked := range list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        hooked.Close()
+    }
+}
+`,
+			wantErr: false,
+		},
+		{
+			name: "Template{ Once: true } replays the call for late items",
+			this: HookTemplate{
+				InterfaceName: "Action",
+				HookName:      "Hook",
+				PackageName:   "main",
+				Methods: []Method{
+					{
+						Name:        "Do",
+						DeclArgs:    []string{"reason string"},
+						CallArgs:    []string{"reason"},
+						Params:      []Param{{Name: "reason", Type: "string"}},
+						DeclResults: []string{},
+						Results:     []Result{},
+					},
+				},
+				Safe: true,
+				Sync: SyncMutex,
+				Once: true,
+			},
+			// Use only spaces for indentation
+			wantW: `// Code generated by hookgen; DO NOT EDIT.
+// github.com/axard/things/cmd/hookgen
+
+package main
+
+import (
+    "sync"
+    "sync/atomic"
+)
+
+// Hook implements Action by calling every registered item. Methods call items
+// one by one in order of priority, items with the same priority in order of
+// registration. It's safe for concurrent use. Every method calls items only
+// once, items appended later are called with the arguments of that call right
+// away.
+type Hook struct {
+    list []*hookedHook
+    m sync.Mutex
+
+    fired  map[string]bool
+    replay []func(Action)
+}
+
+type hookedHook struct {
+    Action
+    priority int
+    once     bool
+    fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook) Append(item Action) HookCancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in order of
+// registration
+func (this *Hook) AppendWithPriority(item Action, priority int) HookCancel {
+    return this.insert(item, priority, false, false)
+}
+
+// Prepend registers item to be called before all registered items
+func (this *Hook) Prepend(item Action) HookCancel {
+    return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook) AppendOnce(item Action) HookCancel {
+    return this.insert(item, 0, false, true)
+}
+
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook) insert(item Action, priority int, first, once bool) HookCancel {
+    this.m.Lock()
+
+    list := this.list
+
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority >= priority {
+            i++
+        }
+    }
+
+    entry := &hookedHook{item, priority, once, 0}
+
+    rest := make([]*hookedHook, 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    this.list = rest
+
+    replay := this.replay
+    this.m.Unlock()
+
+    // calls made before the item is appended are replayed for it
+    for _, call := range replay {
+        if !this.claim(entry) {
+            break
+        }
+
+        call(item)
+    }
+
+    return func() { this.remove(entry) }
+}
+
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook) remove(entry *hookedHook) {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    list := this.list
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hookedHook, 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            this.list = rest
+            break
+        }
+    }
+}
+
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook) claim(entry *hookedHook) bool {
+    if !entry.once {
+        return true
+    }
+
+    if !atomic.CompareAndSwapUint32(&entry.fired, 0, 1) {
+        return false
+    }
+
+    this.remove(entry)
+
+    return true
+}
+
+// record records the first call of the method to replay it for items
+// appended later, it returns the items to call or false if the method has
+// been called before
+func (this *Hook) record(method string, call func(Action)) ([]*hookedHook, bool) {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    if this.fired[method] {
+        return nil, false
+    }
+
+    if this.fired == nil {
+        this.fired = map[string]bool{}
+    }
+
+    this.fired[method] = true
+    this.replay = append(this.replay, call)
+
+    return this.list, true
+}
+
+// items returns the list of hooked items which is never changed in place
+func (this *Hook) items() []*hookedHook {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    return this.list
+}
+
+// Do calls Do of every item.
+func (this *Hook) Do(reason string) {
+    list, ok := this.record("Do", func(item Action) {
+        item.Do(reason)
+    })
+    if !ok {
+        return
+    }
+
+    for _, hooked := range list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        hooked.Do(reason)
+    }
+}
+`,
+			wantErr: false,
+		},
+		{
+			name: "Template{ Recover: true } reports panics of items to OnPanic",
+			this: HookTemplate{
+				InterfaceName: "io.Closer",
+				HookName:      "Hook",
+				PackageName:   "clhook",
+				Imports:       []Import{{Path: "io"}},
+				Methods: []Method{
+					{
+						Name:        "Close",
+						DeclArgs:    []string{},
+						CallArgs:    []string{},
+						DeclResults: []string{"r0 error"},
+						Results: []Result{
+							{Name: "r0", Var: "v0", Type: "error", IsError: true},
+						},
+						Strategy: ResultsFirstError,
+					},
+				},
+				Recover: true,
+			},
+			// Use only spaces for indentation
+			wantW: `// Code generated by hookgen; DO NOT EDIT.
+// github.com/axard/things/cmd/hookgen
+
+package clhook
+
+import (
+    "io"
+)
+
+// Hook implements io.Closer by calling every registered item. Methods call
+// items one by one in order of priority, items with the same priority in order
+// of registration. It isn't safe for concurrent use. Panics of items are
+// recovered and reported to OnPanic.
+type Hook struct {
+    list []*hookedHook
+
+    // OnPanic is called with the item and the value recovered from its
+    // panic, the panic is ignored if OnPanic is nil
+    OnPanic func(item io.Closer, recovered interface{})
+}
+
+type hookedHook struct {
+    io.Closer
+    priority int
+    once     bool
+    fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook) Append(item io.Closer) HookCancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in order of
+// registration
+func (this *Hook) AppendWithPriority(item io.Closer, priority int) HookCancel {
+    return this.insert(item, priority, false, false)
+}
+
+// Prepend registers item to be called before all registered items
+func (this *Hook) Prepend(item io.Closer) HookCancel {
+    return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook) AppendOnce(item io.Closer) HookCancel {
+    return this.insert(item, 0, false, true)
+}
+
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook) insert(item io.Closer, priority int, first, once bool) HookCancel {
+    list := this.list
+
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority >= priority {
+            i++
+        }
+    }
+
+    entry := &hookedHook{item, priority, once, 0}
+
+    rest := make([]*hookedHook, 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    this.list = rest
+
+    return func() { this.remove(entry) }
+}
+
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook) remove(entry *hookedHook) {
+    list := this.list
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hookedHook, 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            this.list = rest
+            break
+        }
+    }
+}
+
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook) claim(entry *hookedHook) bool {
+    if !entry.once {
+        return true
+    }
+
+    if entry.fired != 0 {
+        return false
+    }
+
+    entry.fired = 1
+
+    this.remove(entry)
+
+    return true
+}
+
+// guard calls fn, a panic of the item in it doesn't stop other items
+func (this *Hook) guard(item io.Closer, fn func()) {
+    defer this.rescue(item)
+
+    fn()
+}
+
+// rescue reports the recovered panic of the item to OnPanic, it must be
+// deferred
+func (this *Hook) rescue(item io.Closer) {
+    recovered := recover()
+    if recovered == nil {
+        return
+    }
+
+    if this.OnPanic != nil {
+        this.OnPanic(item, recovered)
+    }
+}
+
+// Close calls Close of every item, it returns results of the first item
+// returning an error and doesn't call the rest.
+func (this *Hook) Close() (r0 error) {
+    for _, hooked := range this.list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        var v0 error
+        this.guard(hooked.Closer, func() {
+            v0 = hooked.Close()
+        })
+        r0 = v0
+        if v0 != nil {
+            return
+        }
+    }
+
+    return
+}
+`,
+			wantErr: false,
+		},
+		{
+			name: "Template{ Dispatch: parallel, Once: true, Recover: true } recovers panics in goroutines and replays",
+			this: HookTemplate{
+				InterfaceName: "Action",
+				HookName:      "Hook",
+				PackageName:   "main",
+				Methods: []Method{
+					{
+						Name:        "Do",
+						DeclArgs:    []string{"reason string"},
+						CallArgs:    []string{"reason"},
+						Params:      []Param{{Name: "reason", Type: "string"}},
+						DeclResults: []string{},
+						Results:     []Result{},
+					},
+				},
+				Dispatch: DispatchParallel,
+				Once:     true,
+				Recover:  true,
+			},
+			// Use only spaces for indentation
+			wantW: `// Code generated by hookgen; DO NOT EDIT.
+// github.com/axard/things/cmd/hookgen
+
+package main
+
+import (
+    "sync"
+)
+
+// Hook implements Action by calling every registered item. Methods call items
+// concurrently and wait for them. It isn't safe for concurrent use. Every
+// method calls items only once, items appended later are called with the
+// arguments of that call right away. Panics of items are recovered and
+// reported to OnPanic.
+type Hook struct {
+    list []*hookedHook
+
+    fired  map[string]bool
+    replay []func(Action)
+
+    // OnPanic is called with the item and the value recovered from its
+    // panic, the panic is ignored if OnPanic is nil
+    OnPanic func(item Action, recovered interface{})
+}
+
+type hookedHook struct {
+    Action
+    priority int
+    once     bool
+    fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook) Append(item Action) HookCancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in order of
+// registration
+func (this *Hook) AppendWithPriority(item Action, priority int) HookCancel {
+    return this.insert(item, priority, false, false)
+}
+
+// Prepend registers item to be called before all registered items
+func (this *Hook) Prepend(item Action) HookCancel {
+    return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook) AppendOnce(item Action) HookCancel {
+    return this.insert(item, 0, false, true)
+}
+
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook) insert(item Action, priority int, first, once bool) HookCancel {
+    list := this.list
+
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority >= priority {
+            i++
+        }
+    }
+
+    entry := &hookedHook{item, priority, once, 0}
+
+    rest := make([]*hookedHook, 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    this.list = rest
+
+    replay := this.replay
+
+    // calls made before the item is appended are replayed for it
+    for _, call := range replay {
+        if !this.claim(entry) {
+            break
+        }
+
+        this.guard(item, func() { call(item) })
+    }
+
+    return func() { this.remove(entry) }
+}
+
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook) remove(entry *hookedHook) {
+    list := this.list
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hookedHook, 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            this.list = rest
+            break
+        }
+    }
+}
+
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook) claim(entry *hookedHook) bool {
+    if !entry.once {
+        return true
+    }
+
+    if entry.fired != 0 {
+        return false
+    }
+
+    entry.fired = 1
+
+    this.remove(entry)
+
+    return true
+}
+
+// claimed returns the items which can be called
+func (this *Hook) claimed(list []*hookedHook) []*hookedHook {
+    rest := make([]*hookedHook, 0, len(list))
+    for _, entry := range list {
+        if this.claim(entry) {
+            rest = append(rest, entry)
+        }
+    }
+
+    return rest
+}
+
+// record records the first call of the method to replay it for items
+// appended later, it returns the items to call or false if the method has
+// been called before
+func (this *Hook) record(method string, call func(Action)) ([]*hookedHook, bool) {
+    if this.fired[method] {
+        return nil, false
+    }
+
+    if this.fired == nil {
+        this.fired = map[string]bool{}
+    }
+
+    this.fired[method] = true
+    this.replay = append(this.replay, call)
+
+    return this.list, true
+}
+
+// guard calls fn, a panic of the item in it doesn't stop other items
+func (this *Hook) guard(item Action, fn func()) {
+    defer this.rescue(item)
+
+    fn()
+}
+
+// rescue reports the recovered panic of the item to OnPanic, it must be
+// deferred
+func (this *Hook) rescue(item Action) {
+    recovered := recover()
+    if recovered == nil {
+        return
+    }
+
+    if this.OnPanic != nil {
+        this.OnPanic(item, recovered)
+    }
+}
+
+// Do calls Do of every item.
+func (this *Hook) Do(reason string) {
+    list, ok := this.record("Do", func(item Action) {
+        item.Do(reason)
+    })
+    if !ok {
+        return
+    }
+
+    list = this.claimed(list)
+
+    wg := sync.WaitGroup{}
+
+    for _, hooked := range list {
+        hooked := hooked
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            defer this.rescue(hooked.Action)
+
+            hooked.Do(reason)
+        }()
+    }
+
+    wg.Wait()
+}
+`,
+			wantErr: false,
+		},
+		{
+			name: "Template{ Funcs: true } adds func adapter for interface with one method",
+			this: HookTemplate{
+				InterfaceName: "Action",
+				HookName:      "Hook",
+				PackageName:   "main",
+				Methods: []Method{
+					{
+						Name:        "Do",
+						DeclArgs:    []string{"reason string"},
+						CallArgs:    []string{"reason"},
+						Params:      []Param{{Name: "reason", Type: "string"}},
+						DeclResults: []string{},
+						Results:     []Result{},
+					},
+				},
+				Funcs: true,
+			},
+			// Use only spaces for indentation
+			wantW: `// Code generated by hookgen; DO NOT EDIT.
+// github.com/axard/things/cmd/hookgen
+
+package main
+
+import (
+)
+
+// Hook implements Action by calling every registered item. Methods call items
+// one by one in order of priority, items with the same priority in order of
+// registration. It isn't safe for concurrent use.
+type Hook struct {
+    list []*hookedHook
+}
+
+type hookedHook struct {
+    Action
+    priority int
+    once     bool
+    fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook) Append(item Action) HookCancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in order of
+// registration
+func (this *Hook) AppendWithPriority(item Action, priority int) HookCancel {
+    return this.insert(item, priority, false, false)
+}
+
+// Prepend registers item to be called before all registered items
+func (this *Hook) Prepend(item Action) HookCancel {
+    return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook) AppendOnce(item Action) HookCancel {
+    return this.insert(item, 0, false, true)
+}
+
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook) insert(item Action, priority int, first, once bool) HookCancel {
+    list := this.list
+
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority >= priority {
+            i++
+        }
+    }
+
+    entry := &hookedHook{item, priority, once, 0}
+
+    rest := make([]*hookedHook, 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    this.list = rest
+
+    return func() { this.remove(entry) }
+}
+
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook) remove(entry *hookedHook) {
+    list := this.list
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hookedHook, 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            this.list = rest
+            break
+        }
+    }
+}
+
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook) claim(entry *hookedHook) bool {
+    if !entry.once {
+        return true
+    }
+
+    if entry.fired != 0 {
+        return false
+    }
+
+    entry.fired = 1
+
+    this.remove(entry)
+
+    return true
+}
+
+// Do calls Do of every item.
+func (this *Hook) Do(reason string) {
+    for _, hooked := range this.list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        hooked.Do(reason)
+    }
+}
+
+// ActionFunc is an adapter to use a function as Action
+type ActionFunc func(reason string)
+
+func (this ActionFunc) Do(reason string) {
+    this(reason)
+}
+`,
+			wantErr: false,
+		},
+		{
+			name: "Template{ Funcs: true } adds struct of funcs for interface with many methods",
+			this: HookTemplate{
+				InterfaceName: "Interface10[K, V]",
+				HookName:      "Hook",
+				PackageName:   "instance",
+				Imports:       []Import{{Path: "fmt"}},
+				Methods: []Method{
+					{
+						Name:        "Set",
+						DeclArgs:    []string{"key K", "values ...V"},
+						CallArgs:    []string{"key", "values..."},
+						Params:      []Param{{Name: "key", Type: "K"}, {Name: "values", Type: "[]V", Variadic: true}},
+						DeclResults: []string{},
+						Results:     []Result{},
+					},
+					{
+						Name:        "Len",
+						DeclArgs:    []string{},
+						CallArgs:    []string{},
+						DeclResults: []string{"r0 int", "r1 error"},
+						Results: []Result{
+							{Name: "r0", Var: "v0", Type: "int"},
+							{Name: "r1", Var: "v1", Type: "error", IsError: true},
+						},
+						Strategy: ResultsLast,
+					},
+				},
+				TypeParams: "[K comparable, V fmt.Stringer]",
+				TypeArgs:   "[K, V]",
+				Funcs:      true,
+			},
+			// Use only spaces for indentation
+			wantW: `// Code generated by hookgen; DO NOT EDIT.
+// github.com/axard/things/cmd/hookgen
+
+package instance
+
+import (
+    "fmt"
+)
+
+// Hook implements Interface10[K, V] by calling every registered item. Methods
+// call items one by one in order of priority, items with the same priority in
+// order of registration. It isn't safe for concurrent use.
+type Hook[K comparable, V fmt.Stringer] struct {
+    list []*hookedHook[K, V]
+}
+
+type hookedHook[K comparable, V fmt.Stringer] struct {
+    Interface10[K, V]
+    priority int
+    once     bool
+    fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook[K, V]) Append(item Interface10[K, V]) HookCancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in order of
+// registration
+func (this *Hook[K, V]) AppendWithPriority(item Interface10[K, V], priority int) HookCancel {
+    return this.insert(item, priority, false, false)
+}
+
+// Prepend registers item to be called before all registered items
+func (this *Hook[K, V]) Prepend(item Interface10[K, V]) HookCancel {
+    return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook[K, V]) AppendOnce(item Interface10[K, V]) HookCancel {
+    return this.insert(item, 0, false, true)
+}
+
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook[K, V]) insert(item Interface10[K, V], priority int, first, once bool) HookCancel {
+    list := this.list
+
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority >= priority {
+            i++
+        }
+    }
+
+    entry := &hookedHook[K, V]{item, priority, once, 0}
+
+    rest := make([]*hookedHook[K, V], 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    this.list = rest
+
+    return func() { this.remove(entry) }
+}
+
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook[K, V]) remove(entry *hookedHook[K, V]) {
+    list := this.list
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hookedHook[K, V], 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            this.list = rest
+            break
+        }
+    }
+}
+
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook[K, V]) claim(entry *hookedHook[K, V]) bool {
+    if !entry.once {
+        return true
+    }
+
+    if entry.fired != 0 {
+        return false
+    }
+
+    entry.fired = 1
+
+    this.remove(entry)
+
+    return true
+}
+
+// Set calls Set of every item.
+func (this *Hook[K, V]) Set(key K, values ...V) {
+    for _, hooked := range this.list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        hooked.Set(key, values...)
+    }
+}
+
+// Len calls Len of every item, it returns results of the last item.
+func (this *Hook[K, V]) Len() (r0 int, r1 error) {
+    for _, hooked := range this.list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        v0, v1 := hooked.Len()
+        r0, r1 = v0, v1
+    }
+
+    return
+}
+
+// Interface10Funcs is an adapter to use functions as Interface10[K, V],
+// methods without function do nothing and return zero values
+type Interface10Funcs[K comparable, V fmt.Stringer] struct {
+    OnSet func(key K, values ...V)
+    OnLen func() (r0 int, r1 error)
+}
+
+func (this Interface10Funcs[K, V]) Set(key K, values ...V) {
+    if this.OnSet != nil {
+        this.OnSet(key, values...)
+    }
+}
+
+func (this Interface10Funcs[K, V]) Len() (r0 int, r1 error) {
+    if this.OnLen != nil {
+        return this.OnLen()
+    }
+
+    return
+}
+`,
+			wantErr: false,
+		},
+		{
+			name: "Template{ Doc: ... } carries comments of the interface",
+			this: HookTemplate{
+				InterfaceName: "Action",
+				HookName:      "Hook",
+				PackageName:   "main",
+				Doc:           "Action is done on exit.\n\nIt's called once.",
+				Methods: []Method{
+					{
+						Name:        "Do",
+						DeclArgs:    []string{"reason string"},
+						CallArgs:    []string{"reason"},
+						Params:      []Param{{Name: "reason", Type: "string"}},
+						DeclResults: []string{},
+						Results:     []Result{},
+						Doc:         "Do does the action.",
+					},
+				},
+				Safe:    true,
+				Sync:    SyncMutex,
+				Once:    true,
+				Recover: true,
+			},
+			// Use only spaces for indentation
+			wantW: `// Code generated by hookgen; DO NOT EDIT.
+// github.com/axard/things/cmd/hookgen
+
+package main
+
+import (
+    "sync"
+    "sync/atomic"
+)
+
+// Hook implements Action by calling every registered item. Methods call items
+// one by one in order of priority, items with the same priority in order of
+// registration. It's safe for concurrent use. Every method calls items only
+// once, items appended later are called with the arguments of that call right
+// away. Panics of items are recovered and reported to OnPanic.
+//
+// Action is done on exit.
+//
+// It's called once.
+type Hook struct {
+    list []*hookedHook
+    m sync.Mutex
+
+    fired  map[string]bool
+    replay []func(Action)
+
+    // OnPanic is called with the item and the value recovered from its
+    // panic, the panic is ignored if OnPanic is nil
+    OnPanic func(item Action, recovered interface{})
+}
+
+type hookedHook struct {
+    Action
+    priority int
+    once     bool
+    fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook) Append(item Action) HookCancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in order of
+// registration
+func (this *Hook) AppendWithPriority(item Action, priority int) HookCancel {
+    return this.insert(item, priority, false, false)
+}
+
+// Prepend registers item to be called before all registered items
+func (this *Hook) Prepend(item Action) HookCancel {
+    return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook) AppendOnce(item Action) HookCancel {
+    return this.insert(item, 0, false, true)
+}
+
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook) insert(item Action, priority int, first, once bool) HookCancel {
+    this.m.Lock()
+
+    list := this.list
+
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority >= priority {
+            i++
+        }
+    }
+
+    entry := &hookedHook{item, priority, once, 0}
+
+    rest := make([]*hookedHook, 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    this.list = rest
+
+    replay := this.replay
+    this.m.Unlock()
+
+    // calls made before the item is appended are replayed for it
+    for _, call := range replay {
+        if !this.claim(entry) {
+            break
+        }
+
+        this.guard(item, func() { call(item) })
+    }
+
+    return func() { this.remove(entry) }
+}
+
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook) remove(entry *hookedHook) {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    list := this.list
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hookedHook, 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            this.list = rest
+            break
+        }
+    }
+}
+
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook) claim(entry *hookedHook) bool {
+    if !entry.once {
+        return true
+    }
+
+    if !atomic.CompareAndSwapUint32(&entry.fired, 0, 1) {
+        return false
+    }
+
+    this.remove(entry)
+
+    return true
+}
+
+// record records the first call of the method to replay it for items
+// appended later, it returns the items to call or false if the method has
+// been called before
+func (this *Hook) record(method string, call func(Action)) ([]*hookedHook, bool) {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    if this.fired[method] {
+        return nil, false
+    }
+
+    if this.fired == nil {
+        this.fired = map[string]bool{}
+    }
+
+    this.fired[method] = true
+    this.replay = append(this.replay, call)
+
+    return this.list, true
+}
+
+// guard calls fn, a panic of the item in it doesn't stop other items
+func (this *Hook) guard(item Action, fn func()) {
+    defer this.rescue(item)
+
+    fn()
+}
+
+// rescue reports the recovered panic of the item to OnPanic, it must be
+// deferred
+func (this *Hook) rescue(item Action) {
+    recovered := recover()
+    if recovered == nil {
+        return
+    }
+
+    if this.OnPanic != nil {
+        this.OnPanic(item, recovered)
+    }
+}
+
+// items returns the list of hooked items which is never changed in place
+func (this *Hook) items() []*hookedHook {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    return this.list
+}
+
+// Do calls Do of every item.
+//
+// Do does the action.
+func (this *Hook) Do(reason string) {
+    list, ok := this.record("Do", func(item Action) {
+        item.Do(reason)
+    })
+    if !ok {
+        return
+    }
+
+    for _, hooked := range list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        this.guard(hooked.Action, func() {
+            hooked.Do(reason)
+        })
+    }
+}
+`,
+			wantErr: false,
+		},
+		{
+			name: "Recorder records calls and returns results of items",
+			this: HookTemplate{
+				InterfaceName: "Listener",
+				HookName:      "Hook",
+				PackageName:   "events",
+				Methods: []Method{
+					{
+						Name:        "Check",
+						DeclArgs:    []string{},
+						CallArgs:    []string{},
+						Params:      []Param{},
+						DeclResults: []string{"r0 []bool"},
+						Results:     []Result{{Name: "r0", Var: "v0", Type: "bool"}},
+						Strategy:    ResultsAll,
+					},
+					{
+						Name:        "Notify",
+						DeclArgs:    []string{"e string", "tags ...string"},
+						CallArgs:    []string{"e", "tags..."},
+						Params:      []Param{{Name: "e", Type: "string"}, {Name: "tags", Type: "[]string", Variadic: true}},
+						DeclResults: []string{},
+						Results:     []Result{},
+					},
+				},
+				Sync:     SyncMutex,
+				Dispatch: DispatchSequential,
+				Order:    OrderFIFO,
+				Recorder: true,
+			},
+			// Use only spaces for indentation
+			wantW: `// Code generated by hookgen; DO NOT EDIT.
+// github.com/axard/things/cmd/hookgen
+
+package events
+
+import (
+    "sync"
+    "time"
+)
+
+// Hook implements Listener by calling every registered item. Methods call
+// items one by one in order of priority, items with the same priority in order
+// of registration. It isn't safe for concurrent use.
+type Hook struct {
+    list []*hookedHook
+}
+
+type hookedHook struct {
+    Listener
+    priority int
+    once     bool
+    fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook) Append(item Listener) HookCancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in order of
+// registration
+func (this *Hook) AppendWithPriority(item Listener, priority int) HookCancel {
+    return this.insert(item, priority, false, false)
+}
+
+// Prepend registers item to be called before all registered items
+func (this *Hook) Prepend(item Listener) HookCancel {
+    return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook) AppendOnce(item Listener) HookCancel {
+    return this.insert(item, 0, false, true)
+}
+
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook) insert(item Listener, priority int, first, once bool) HookCancel {
+    list := this.list
+
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority >= priority {
+            i++
+        }
+    }
+
+    entry := &hookedHook{item, priority, once, 0}
+
+    rest := make([]*hookedHook, 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    this.list = rest
+
+    return func() { this.remove(entry) }
+}
+
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook) remove(entry *hookedHook) {
+    list := this.list
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hookedHook, 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            this.list = rest
+            break
+        }
+    }
+}
+
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook) claim(entry *hookedHook) bool {
+    if !entry.once {
+        return true
+    }
+
+    if entry.fired != 0 {
+        return false
+    }
+
+    entry.fired = 1
+
+    this.remove(entry)
+
+    return true
+}
+
+// Check calls Check of every item, it returns results of all items.
+func (this *Hook) Check() (r0 []bool) {
+    for _, hooked := range this.list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        v0 := hooked.Check()
+        r0 = append(r0, v0)
+    }
+
+    return
+}
+
+// Notify calls Notify of every item.
+func (this *Hook) Notify(e string, tags ...string) {
+    for _, hooked := range this.list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        hooked.Notify(e, tags...)
+    }
+}
+
+// ListenerRecorder implements Listener by recording every call with its
+// arguments, methods return zero values. It's safe for concurrent use, its
+// zero value is ready to use.
+type ListenerRecorder struct {
+    m       sync.Mutex
+    calls   []ListenerCall
+    changed chan struct{}
+}
+
+// ListenerCall is the call recorded by ListenerRecorder, only the field of the
+// called method is set
+type ListenerCall struct {
+    Check *ListenerCheckCall
+    Notify *ListenerNotifyCall
+}
+
+// ListenerCheckCall is arguments of the recorded call of Check
+type ListenerCheckCall struct {
+}
+
+// ListenerNotifyCall is arguments of the recorded call of Notify
+type ListenerNotifyCall struct {
+    E string
+    Tags []string
+}
+
+func (this *ListenerRecorder) Check() (r0 bool) {
+    this.record(ListenerCall{Check: &ListenerCheckCall{}})
+
+    return
+}
+
+func (this *ListenerRecorder) Notify(e string, tags ...string) {
+    this.record(ListenerCall{Notify: &ListenerNotifyCall{E: e, Tags: tags}})
+}
+
+func (this *ListenerRecorder) record(call ListenerCall) {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    this.calls = append(this.calls, call)
+
+    // wake up callers of WaitForCalls
+    if this.changed != nil {
+        close(this.changed)
+        this.changed = nil
+    }
+}
+
+// Calls returns recorded calls in order they were made
+func (this *ListenerRecorder) Calls() []ListenerCall {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    return append([]ListenerCall{}, this.calls...)
+}
+
+// Reset forgets recorded calls
+func (this *ListenerRecorder) Reset() {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    this.calls = nil
+}
+
+// WaitForCalls waits until at least n calls are recorded, it returns false
+// if they aren't recorded before timeout
+func (this *ListenerRecorder) WaitForCalls(n int, timeout time.Duration) bool {
+    timer := time.NewTimer(timeout)
+    defer timer.Stop()
+
+    for {
+        this.m.Lock()
+        count := len(this.calls)
+        if this.changed == nil {
+            this.changed = make(chan struct{})
+        }
+        changed := this.changed
+        this.m.Unlock()
+
+        if count >= n {
+            return true
+        }
+
+        select {
+        case <-changed:
+        case <-timer.C:
+            return false
+        }
+    }
+}
+`,
+			wantErr: false,
+		},
+		{
+			name: "Funcs adapters return results of items with all strategy",
+			this: HookTemplate{
+				InterfaceName: "Firsty",
+				HookName:      "Hook",
+				PackageName:   "ev",
+				Methods: []Method{
+					{
+						Name:        "P",
+						DeclArgs:    []string{},
+						CallArgs:    []string{},
+						Params:      []Param{},
+						DeclResults: []string{"r0 []Pair", "r1 []bool"},
+						Results:     []Result{{Name: "r0", Var: "v0", Type: "Pair"}, {Name: "r1", Var: "v1", Type: "bool"}},
+						Strategy:    ResultsAll,
+					},
+					{
+						Name:        "Q",
+						DeclArgs:    []string{},
+						CallArgs:    []string{},
+						Params:      []Param{},
+						DeclResults: []string{"r0 []int"},
+						Results:     []Result{{Name: "r0", Var: "v0", Type: "int"}},
+						Strategy:    ResultsAll,
+					},
+				},
+				Sync:     SyncMutex,
+				Dispatch: DispatchSequential,
+				Order:    OrderFIFO,
+				Funcs:    true,
+			},
+			// Use only spaces for indentation
+			wantW: `// Code generated by hookgen; DO NOT EDIT.
+// github.com/axard/things/cmd/hookgen
+
+package ev
+
+import (
+)
+
+// Hook implements Firsty by calling every registered item. Methods call items
+// one by one in order of priority, items with the same priority in order of
+// registration. It isn't safe for concurrent use.
+type Hook struct {
+    list []*hookedHook
+}
+
+type hookedHook struct {
+    Firsty
+    priority int
+    once     bool
+    fired    uint32
+}
+
+// HookCancel removes the registered item
+type HookCancel = func()
+
+// Append registers item with zero priority
+func (this *Hook) Append(item Firsty) HookCancel {
+    return this.insert(item, 0, false, false)
+}
+
+// AppendWithPriority registers item to be called before items with lower
+// priority, items with the same priority are called in order of
+// registration
+func (this *Hook) AppendWithPriority(item Firsty, priority int) HookCancel {
+    return this.insert(item, priority, false, false)
+}
+
+// Prepend registers item to be called before all registered items
+func (this *Hook) Prepend(item Firsty) HookCancel {
+    return this.insert(item, 0, true, false)
+}
+
+// AppendOnce registers item to be called only once, it's removed before its
+// first call
+func (this *Hook) AppendOnce(item Firsty) HookCancel {
+    return this.insert(item, 0, false, true)
+}
+
+// insert keeps the list ordered by priority, like remove it doesn't change
+// the list in place
+func (this *Hook) insert(item Firsty, priority int, first, once bool) HookCancel {
+    list := this.list
+
+    i := 0
+    if first {
+        if len(list) != 0 {
+            priority = list[0].priority
+        }
+    } else {
+        for i < len(list) && list[i].priority >= priority {
+            i++
+        }
+    }
+
+    entry := &hookedHook{item, priority, once, 0}
+
+    rest := make([]*hookedHook, 0, len(list)+1)
+    rest = append(rest, list[:i]...)
+    rest = append(rest, entry)
+    rest = append(rest, list[i:]...)
+    this.list = rest
+
+    return func() { this.remove(entry) }
+}
+
+// remove doesn't change the list in place, so the method being called
+// iterates over the items registered before its call
+func (this *Hook) remove(entry *hookedHook) {
+    list := this.list
+
+    for i := range list {
+        if list[i] == entry {
+            rest := make([]*hookedHook, 0, len(list)-1)
+            rest = append(rest, list[:i]...)
+            rest = append(rest, list[i+1:]...)
+            this.list = rest
+            break
+        }
+    }
+}
+
+// claim reports whether the item can be called, item appended by
+// AppendOnce is removed before its first call
+func (this *Hook) claim(entry *hookedHook) bool {
+    if !entry.once {
+        return true
+    }
+
+    if entry.fired != 0 {
+        return false
+    }
+
+    entry.fired = 1
+
+    this.remove(entry)
+
+    return true
+}
+
+// P calls P of every item, it returns results of all items.
+func (this *Hook) P() (r0 []Pair, r1 []bool) {
+    for _, hooked := range this.list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        v0, v1 := hooked.P()
+        r0 = append(r0, v0)
+        r1 = append(r1, v1)
+    }
+
+    return
+}
+
+// Q calls Q of every item, it returns results of all items.
+func (this *Hook) Q() (r0 []int) {
+    for _, hooked := range this.list {
+        if !this.claim(hooked) {
+            continue
+        }
+
+        v0 := hooked.Q()
+        r0 = append(r0, v0)
+    }
+
+    return
+}
+
+// FirstyFuncs is an adapter to use functions as Firsty,
+// methods without function do nothing and return zero values
+type FirstyFuncs struct {
+    OnP func() (r0 Pair, r1 bool)
+    OnQ func() (r0 int)
+}
+
+func (this FirstyFuncs) P() (r0 Pair, r1 bool) {
+    if this.OnP != nil {
+        return this.OnP()
+    }
+
+    return
+}
+
+func (this FirstyFuncs) Q() (r0 int) {
+    if this.OnQ != nil {
+        return this.OnQ()
+    }
+
+    return
+}
+`,
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			if err := tt.this.Write(w); (err != nil) != tt.wantErr {
+				t.Errorf("Template.Write() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotW := w.String(); gotW != tt.wantW {
+				t.Errorf("Template.Write() = %v, want %v", gotW, tt.wantW)
+			}
+		})
+	}
+}
+
+func TestHookTemplate_ExecuteCustom(t *testing.T) {
+	hook := HookTemplate{
+		InterfaceName: "Closer",
+		HookName:      "Hook",
+		PackageName:   "clhook",
+		Methods: []Method{
+			{
+				Name:        "Close",
+				DeclArgs:    []string{},
+				CallArgs:    []string{},
+				DeclResults: []string{"r0 error"},
+				Results: []Result{
+					{Name: "r0", Var: "v0", Type: "error", IsError: true},
+				},
+				Strategy: ResultsFirstError,
+				Doc:      "Close closes.",
+			},
+		},
+	}
+
+	type args struct {
+		text string
+	}
+	tests := []struct {
+		name    string
+		this    HookTemplate
+		args    args
+		wantW   string
+		wantErr bool
+	}{
+		{
+			name: "custom template uses FuncMap and descriptors of methods",
+			this: hook,
+			args: args{
+				text: `{{range .Methods}}{{comment .Doc}}
+log.Println({{quote (lower .Name)}}, {{join .ResultNames ", "}})
+{{end}}`,
+			},
+			wantW: `// Close closes.
+log.Println("close", r0)
+`,
+			wantErr: false,
+		},
+		{
+			name: "custom template uses templates of the built-in one",
+			this: hook,
+			args: args{
+				text: `{{range .Methods}}{{template "aggregate" .}}{{end}}`,
+			},
+			wantW: `
+        r0 = v0
+        if v0 != nil {
+            return
+        }`,
+			wantErr: false,
+		},
+		{
+			name: "custom template with syntax error",
+			this: hook,
+			args: args{
+				text: `{{range .Methods}}`,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			if err := tt.this.Execute(w, tt.args.text); (err != nil) != tt.wantErr {
+				t.Errorf("HookTemplate.Execute() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotW := w.String(); gotW != tt.wantW {
+				t.Errorf("HookTemplate.Execute() = %q, want %q", gotW, tt.wantW)
+			}
+		})
+	}
+}
+
+func TestParam_Field(t *testing.T) {
+	tests := []struct {
+		name  string
+		param Param
+		want  string
+	}{
+		{
+			name:  "unexported name",
+			param: Param{Name: "ctx", Type: "context.Context"},
+			want:  "Ctx",
+		},
+		{
+			name:  "generated name",
+			param: Param{Name: "arg0", Type: "int"},
+			want:  "Arg0",
+		},
+		{
+			name:  "exported name",
+			param: Param{Name: "K", Type: "K"},
+			want:  "K",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.param.Field(); got != tt.want {
+				t.Errorf("Param.Field() = %v, want %v", got, tt.want)
 			}
 		})
 	}
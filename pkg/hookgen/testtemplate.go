@@ -0,0 +1,208 @@
+package hookgen
+
+import (
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Use only spaces for indentation
+const testtemplate = `// Code generated by hookgen; DO NOT EDIT.
+// github.com/axard/things/cmd/hookgen
+
+package {{.PackageName}}
+
+import (
+    {{- range .Imports }}
+    {{with .Name}}{{.}} {{end}}"{{.Path}}"
+    {{- end }}
+    {{- if not (.Imported "reflect")}}
+    "reflect"
+    {{- end}}
+    {{- if and (eq .Dispatch "parallel") (not (.Imported "sort"))}}
+    "sort"
+    {{- end}}
+    {{- if not (.Imported "sync")}}
+    "sync"
+    {{- end}}
+    {{- if not (.Imported "testing")}}
+    "testing"
+    {{- end}}
+)
+
+// test{{.HookName}}Calls records ids of called items
+type test{{.HookName}}Calls struct {
+    m   sync.Mutex
+    ids []int
+}
+
+func (this *test{{.HookName}}Calls) add(id int) {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    this.ids = append(this.ids, id)
+}
+
+func (this *test{{.HookName}}Calls) list() []int {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    ids := append([]int{}, this.ids...)
+    {{- if eq .Dispatch "parallel"}}
+
+    // items are called concurrently, so the order of calls is unknown
+    sort.Ints(ids)
+    {{- end}}
+
+    return ids
+}
+
+// test{{.HookName}}Item records its id on every call and returns zero values
+type test{{.HookName}}Item struct {
+    id    int
+    calls *test{{.HookName}}Calls
+}
+
+var _ {{.InterfaceName}} = test{{.HookName}}Item{}
+{{- range .Methods}}
+
+func (this test{{$.HookName}}Item) {{.Name}}({{join .DeclArgs ", "}}){{with .ItemDeclResults}} ({{join . ", "}}){{end}} {
+    this.calls.add(this.id)
+    {{- if .Results}}
+
+    return
+    {{- end}}
+}
+{{- end}}
+
+// test{{.HookName}}Methods call methods of the hook with zero arguments
+var test{{.HookName}}Methods = map[string]func(hook *{{.HookName}}){
+    {{- range .Methods}}
+    "{{.Name}}": func(hook *{{$.HookName}}) {
+        {{- $ctx := .Context}}
+        {{- range .Params}}
+        {{- if eq .Name $ctx}}
+        {{.Name}} := {{background .Type}}
+        {{- else}}
+        var {{.Name}} {{.Type}}
+        {{- end}}
+        {{- end}}
+        hook.{{.Name}}({{join .CallArgs ", "}})
+    },
+    {{- end}}
+}
+
+// fireTest{{.HookName}} calls the method and waits until items are called
+func fireTest{{.HookName}}(hook *{{.HookName}}, method string) {
+    {{- if eq .Dispatch "async"}}
+    hook.Start()
+    defer hook.Close()
+
+    {{end -}}
+    test{{.HookName}}Methods[method](hook)
+}
+
+func Test{{.TestName}}_order(t *testing.T) {
+    for method := range test{{.HookName}}Methods {
+        t.Run(method, func(t *testing.T) {
+            calls := &test{{.HookName}}Calls{}
+            hook := &{{.HookName}}{}
+
+            hook.Append(test{{.HookName}}Item{0, calls})
+            hook.Append(test{{.HookName}}Item{1, calls})
+            hook.Prepend(test{{.HookName}}Item{2, calls})
+            hook.AppendWithPriority(test{{.HookName}}Item{3, calls}, 1)
+
+            fireTest{{.HookName}}(hook, method)
+
+            want := []int{ {{- if eq .Dispatch "parallel"}}0, 1, 2, 3{{else if eq .Order "lifo"}}3, 2, 1, 0{{else}}3, 2, 0, 1{{end -}} }
+            if got := calls.list(); !reflect.DeepEqual(got, want) {
+                t.Errorf("{{.HookName}}.%s() calls items %v, want %v", method, got, want)
+            }
+        })
+    }
+}
+
+func Test{{.TestName}}_cancel(t *testing.T) {
+    for method := range test{{.HookName}}Methods {
+        t.Run(method, func(t *testing.T) {
+            calls := &test{{.HookName}}Calls{}
+            hook := &{{.HookName}}{}
+
+            hook.Append(test{{.HookName}}Item{0, calls})
+            cancel := hook.Append(test{{.HookName}}Item{1, calls})
+            hook.Append(test{{.HookName}}Item{2, calls})
+
+            cancel()
+            // the second call removes nothing
+            cancel()
+
+            fireTest{{.HookName}}(hook, method)
+
+            want := []int{ {{- if and (ne .Dispatch "parallel") (eq .Order "lifo")}}2, 0{{else}}0, 2{{end -}} }
+            if got := calls.list(); !reflect.DeepEqual(got, want) {
+                t.Errorf("{{.HookName}}.%s() calls items %v, want %v", method, got, want)
+            }
+        })
+    }
+}
+{{- if .Safe}}
+
+// Test{{.TestName}}_concurrent is useful with -race
+func Test{{.TestName}}_concurrent(t *testing.T) {
+    calls := &test{{.HookName}}Calls{}
+    hook := &{{.HookName}}{}
+    {{- if eq .Dispatch "async"}}
+
+    hook.Start()
+    defer hook.Close()
+    {{- end}}
+
+    wg := sync.WaitGroup{}
+
+    for i := 0; i < 8; i++ {
+        wg.Add(2)
+
+        go func(id int) {
+            defer wg.Done()
+
+            cancel := hook.Append(test{{.HookName}}Item{id, calls})
+            cancel()
+        }(i)
+
+        go func() {
+            defer wg.Done()
+
+            for _, call := range test{{.HookName}}Methods {
+                call(hook)
+            }
+        }()
+    }
+
+    wg.Wait()
+}
+{{- end}}
+`
+
+// TestName returns the name of the hook in names of tests, it begins with
+// "_" if the hook isn't exported.
+func (this HookTemplate) TestName() string {
+	r, _ := utf8.DecodeRuneInString(this.HookName)
+	if unicode.IsUpper(r) {
+		return this.HookName
+	}
+
+	return "_" + this.HookName
+}
+
+// background returns the expression for context of type t like
+// "context.Background()" for "context.Context"
+func background(t string) string {
+	return strings.TrimSuffix(t, "Context") + "Background()"
+}
+
+// WriteTests writes tests of the hook.
+func (this HookTemplate) WriteTests(w io.Writer) error {
+	return this.Execute(w, testtemplate)
+}
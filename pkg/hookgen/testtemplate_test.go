@@ -0,0 +1,219 @@
+package hookgen
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHookTemplate_WriteTests(t *testing.T) {
+	tests := []struct {
+		name    string
+		this    HookTemplate
+		wantW   string
+		wantErr bool
+	}{
+		{
+			name: "Template{ Safe: true, Dispatch: parallel } tests calls of items in any order",
+			this: HookTemplate{
+				InterfaceName: "Stopper",
+				HookName:      "Hook",
+				PackageName:   "stop",
+				Imports:       []Import{{Path: "context"}},
+				Methods: []Method{
+					{
+						Name:        "Stop",
+						DeclArgs:    []string{"ctx context.Context", "reason string"},
+						CallArgs:    []string{"ctx", "reason"},
+						Params:      []Param{{Name: "ctx", Type: "context.Context"}, {Name: "reason", Type: "string"}},
+						DeclResults: []string{"r0 error"},
+						Results:     []Result{{Name: "r0", Var: "v0", Type: "error", IsError: true}},
+						Strategy:    ResultsFirstError,
+						Context:     "ctx",
+					},
+				},
+				Safe:     true,
+				Sync:     SyncMutex,
+				Dispatch: DispatchParallel,
+			},
+			// Use only spaces for indentation
+			wantW: `// Code generated by hookgen; DO NOT EDIT.
+// github.com/axard/things/cmd/hookgen
+
+package stop
+
+import (
+    "context"
+    "reflect"
+    "sort"
+    "sync"
+    "testing"
+)
+
+// testHookCalls records ids of called items
+type testHookCalls struct {
+    m   sync.Mutex
+    ids []int
+}
+
+func (this *testHookCalls) add(id int) {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    this.ids = append(this.ids, id)
+}
+
+func (this *testHookCalls) list() []int {
+    this.m.Lock()
+    defer this.m.Unlock()
+
+    ids := append([]int{}, this.ids...)
+
+    // items are called concurrently, so the order of calls is unknown
+    sort.Ints(ids)
+
+    return ids
+}
+
+// testHookItem records its id on every call and returns zero values
+type testHookItem struct {
+    id    int
+    calls *testHookCalls
+}
+
+var _ Stopper = testHookItem{}
+
+func (this testHookItem) Stop(ctx context.Context, reason string) (r0 error) {
+    this.calls.add(this.id)
+
+    return
+}
+
+// testHookMethods call methods of the hook with zero arguments
+var testHookMethods = map[string]func(hook *Hook){
+    "Stop": func(hook *Hook) {
+        ctx := context.Background()
+        var reason string
+        hook.Stop(ctx, reason)
+    },
+}
+
+// fireTestHook calls the method and waits until items are called
+func fireTestHook(hook *Hook, method string) {testHookMethods[method](hook)
+}
+
+func TestHook_order(t *testing.T) {
+    for method := range testHookMethods {
+        t.Run(method, func(t *testing.T) {
+            calls := &testHookCalls{}
+            hook := &Hook{}
+
+            hook.Append(testHookItem{0, calls})
+            hook.Append(testHookItem{1, calls})
+            hook.Prepend(testHookItem{2, calls})
+            hook.AppendWithPriority(testHookItem{3, calls}, 1)
+
+            fireTestHook(hook, method)
+
+            want := []int{0, 1, 2, 3}
+            if got := calls.list(); !reflect.DeepEqual(got, want) {
+                t.Errorf("Hook.%s() calls items %v, want %v", method, got, want)
+            }
+        })
+    }
+}
+
+func TestHook_cancel(t *testing.T) {
+    for method := range testHookMethods {
+        t.Run(method, func(t *testing.T) {
+            calls := &testHookCalls{}
+            hook := &Hook{}
+
+            hook.Append(testHookItem{0, calls})
+            cancel := hook.Append(testHookItem{1, calls})
+            hook.Append(testHookItem{2, calls})
+
+            cancel()
+            // the second call removes nothing
+            cancel()
+
+            fireTestHook(hook, method)
+
+            want := []int{0, 2}
+            if got := calls.list(); !reflect.DeepEqual(got, want) {
+                t.Errorf("Hook.%s() calls items %v, want %v", method, got, want)
+            }
+        })
+    }
+}
+
+// TestHook_concurrent is useful with -race
+func TestHook_concurrent(t *testing.T) {
+    calls := &testHookCalls{}
+    hook := &Hook{}
+
+    wg := sync.WaitGroup{}
+
+    for i := 0; i < 8; i++ {
+        wg.Add(2)
+
+        go func(id int) {
+            defer wg.Done()
+
+            cancel := hook.Append(testHookItem{id, calls})
+            cancel()
+        }(i)
+
+        go func() {
+            defer wg.Done()
+
+            for _, call := range testHookMethods {
+                call(hook)
+            }
+        }()
+    }
+
+    wg.Wait()
+}
+`,
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			if err := tt.this.WriteTests(w); (err != nil) != tt.wantErr {
+				t.Errorf("HookTemplate.WriteTests() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotW := w.String(); gotW != tt.wantW {
+				t.Errorf("HookTemplate.WriteTests() = %v, want %v", gotW, tt.wantW)
+			}
+		})
+	}
+}
+
+func TestHookTemplate_TestName(t *testing.T) {
+	tests := []struct {
+		name string
+		this HookTemplate
+		want string
+	}{
+		{
+			name: "exported hook",
+			this: HookTemplate{HookName: "Hook"},
+			want: "Hook",
+		},
+		{
+			name: "unexported hook",
+			this: HookTemplate{HookName: "hook"},
+			want: "_hook",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.this.TestName(); got != tt.want {
+				t.Errorf("HookTemplate.TestName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
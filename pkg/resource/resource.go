@@ -2,14 +2,66 @@ package resource
 
 import (
 	"path"
+	"path/filepath"
 	"strings"
 )
 
 func Package(resource string) string {
-	d, f := path.Split(resource)
+	d, f := path.Split(trimTypeArgs(resource))
 	return d + strings.TrimSuffix(f, path.Ext(f))
 }
 
 func Object(resource string) string {
-	return strings.TrimLeft(path.Ext(resource), ".")
+	return strings.TrimLeft(path.Ext(trimTypeArgs(resource)), ".")
+}
+
+// TypeArgs returns type arguments of resource like
+// "/path/to/package.Resource[int, map[string]pkg.Type]", it returns nil if
+// resource has no type arguments
+func TypeArgs(resource string) []string {
+	i := strings.Index(resource, "[")
+	if i < 0 || !strings.HasSuffix(resource, "]") {
+		return nil
+	}
+
+	args := []string{}
+	depth, begin, end := 0, i+1, len(resource)-1
+
+	for j := begin; j < end; j++ {
+		switch resource[j] {
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(resource[begin:j]))
+				begin = j + 1
+			}
+		}
+	}
+
+	return append(args, strings.TrimSpace(resource[begin:end]))
+}
+
+// trimTypeArgs removes type arguments from resource, the path of package
+// can't contain brackets so they begin with the first one
+func trimTypeArgs(resource string) string {
+	if i := strings.Index(resource, "["); i >= 0 {
+		return resource[:i]
+	}
+
+	return resource
+}
+
+// IsLocal reports whether resource is a path in the file system and not an
+// import path, like the go command it treats as local only absolute paths
+// and paths beginning with "." or ".."
+func IsLocal(resource string) bool {
+	if path.IsAbs(resource) || filepath.IsAbs(resource) {
+		return true
+	}
+
+	return resource == "." || resource == ".." ||
+		strings.HasPrefix(resource, "./") || strings.HasPrefix(resource, "../")
 }
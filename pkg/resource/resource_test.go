@@ -1,6 +1,7 @@
 package resource
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -34,6 +35,13 @@ func TestPackage(t *testing.T) {
 			},
 			want: "/path/to/package",
 		},
+		{
+			name: "Package() returns valid path if resource has type arguments",
+			args: args{
+				resource: "/path/to/package.Resource[other/pkg.Type]",
+			},
+			want: "/path/to/package",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -81,6 +89,13 @@ func TestObject(t *testing.T) {
 			},
 			want: "Resource",
 		},
+		{
+			name: "Object() returns object name without type arguments",
+			args: args{
+				resource: "/path/to/package.Resource[pkg.Type]",
+			},
+			want: "Resource",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -90,3 +105,97 @@ func TestObject(t *testing.T) {
 		})
 	}
 }
+
+func TestIsLocal(t *testing.T) {
+	type args struct {
+		resource string
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "IsLocal() returns true for absolute path",
+			args: args{
+				resource: "/path/to/package.Resource",
+			},
+			want: true,
+		},
+		{
+			name: "IsLocal() returns true for relative path",
+			args: args{
+				resource: "./path/to/package.Resource",
+			},
+			want: true,
+		},
+		{
+			name: "IsLocal() returns true for path to parent",
+			args: args{
+				resource: "../package.Resource",
+			},
+			want: true,
+		},
+		{
+			name: "IsLocal() returns false for import path",
+			args: args{
+				resource: "github.com/axard/things/pkg/resource.Resource",
+			},
+			want: false,
+		},
+		{
+			name: "IsLocal() returns false for standard package",
+			args: args{
+				resource: "io.Closer",
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsLocal(tt.args.resource); got != tt.want {
+				t.Errorf("IsLocal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypeArgs(t *testing.T) {
+	type args struct {
+		resource string
+	}
+	tests := []struct {
+		name string
+		args args
+		want []string
+	}{
+		{
+			name: "TypeArgs() returns nil if resource has no type arguments",
+			args: args{
+				resource: "/path/to/package.Resource",
+			},
+			want: nil,
+		},
+		{
+			name: "TypeArgs() returns one argument",
+			args: args{
+				resource: "./package.Resource[pkg.Type]",
+			},
+			want: []string{"pkg.Type"},
+		},
+		{
+			name: "TypeArgs() splits arguments only at top level",
+			args: args{
+				resource: "./package.Resource[map[string]int, func(a, b int), struct{ a, b int }]",
+			},
+			want: []string{"map[string]int", "func(a, b int)", "struct{ a, b int }"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TypeArgs(tt.args.resource); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TypeArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}